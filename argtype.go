@@ -0,0 +1,135 @@
+package kgo
+
+import (
+	"strings"
+)
+
+// The kind of value an argument expects, guessed from its name
+type ArgType string
+
+const (
+	ArgString    ArgType = "string"
+	ArgFile      ArgType = "file"
+	ArgDirectory ArgType = "directory"
+	ArgHost      ArgType = "host"
+	ArgUser      ArgType = "user"
+	ArgInteger   ArgType = "integer"
+	ArgDuration  ArgType = "duration"
+	ArgByteSize  ArgType = "bytesize"
+)
+
+// Keywords looked for in argument names, checked in order so that the
+// more specific names win (eg directory before file)
+var argTypeKeywords = []struct {
+	keyword string
+	argtype ArgType
+}{
+	{"timeout", ArgDuration},
+	{"seconds", ArgDuration},
+	{"interval", ArgDuration},
+	{"delay", ArgDuration},
+	{"duration", ArgDuration},
+	{"bytes", ArgByteSize},
+	{"size", ArgByteSize},
+	{"dir", ArgDirectory},
+	{"hostname", ArgHost},
+	{"host", ArgHost},
+	{"login", ArgUser},
+	{"user", ArgUser},
+	{"owner", ArgUser},
+	{"file", ArgFile},
+	{"path", ArgFile},
+	{"config", ArgFile},
+	// a destination is usually a file, eg cp source dest
+	{"dest", ArgFile},
+	{"port", ArgInteger},
+	{"num", ArgInteger},
+	{"count", ArgInteger},
+	{"depth", ArgInteger},
+	{"level", ArgInteger},
+}
+
+// Argument names too short to look for inside longer names, so only
+// matched when they're the whole name
+var argTypeNames = map[string]ArgType{
+	"n":    ArgInteger,
+	"s":    ArgDuration,
+	"sec":  ArgDuration,
+	"secs": ArgDuration,
+	"ms":   ArgDuration,
+	"kb":   ArgByteSize,
+	"mb":   ArgByteSize,
+	"gb":   ArgByteSize,
+}
+
+// Guess the type of an argument from its name. Argument names are
+// freeform so anything not recognised is just a string.
+func classifyArgument(name string) ArgType {
+	lower := strings.ToLower(name)
+	if t, ok := argTypeNames[lower]; ok {
+		return t
+	}
+	for _, kw := range argTypeKeywords {
+		if strings.Contains(lower, kw.keyword) {
+			return kw.argtype
+		}
+	}
+	return ArgString
+}
+
+// The compgen options completing an argument of the given type
+func bashAction(t ArgType) string {
+	switch t {
+	case ArgFile:
+		return "-f"
+	case ArgDirectory:
+		return "-d"
+	case ArgHost:
+		return "-A hostname"
+	case ArgUser:
+		return "-A user"
+	}
+	return ""
+}
+
+// The zsh completion function for an argument of the given type
+func zshAction(t ArgType) string {
+	switch t {
+	case ArgFile:
+		return "_files"
+	case ArgDirectory:
+		return "_directories"
+	case ArgHost:
+		return "_hosts"
+	case ArgUser:
+		return "_users"
+	case ArgInteger:
+		return "_numbers"
+	}
+	return ""
+}
+
+// The options to fish's complete offering arguments of the given type.
+// Files are offered by default, so only other types need a list of
+// candidates.
+func fishAction(t ArgType) string {
+	switch t {
+	case ArgFile:
+		return "-F"
+	case ArgDirectory:
+		return "-f -a '(__fish_complete_directories)'"
+	case ArgHost:
+		return "-f -a '(__fish_print_hostnames)'"
+	case ArgUser:
+		return "-f -a '(__fish_complete_users)'"
+	}
+	return "-f"
+}
+
+// The type of the parameter's argument, or empty if it has none
+func (p Parameter) argType() ArgType {
+	if !p.HasArgument {
+		return ""
+	}
+	return classifyArgument(p.Argument)
+}
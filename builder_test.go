@@ -0,0 +1,247 @@
+package kgo
+
+import (
+	"testing"
+)
+
+// A fluent builder for Command values, for writing out the commands a
+// parser is expected to produce without spelling out every field and
+// nested pointer by hand, eg
+//
+//	newCmd("ls").syntax().
+//		flag("a").optional().
+//		arg("file").optional().variadic().
+//		build()
+//
+// describes `ls [-a] [file ...]`. Flag and arg always start a new
+// parameter (as does keyword), and the calls after them modify that
+// parameter until the next flag, arg or syntax call. nest starts a
+// parameter nested in the current one, whose fields are set with
+// withFlag and withArg, so
+//
+//	newCmd("ssh").syntax().
+//		flag("w").optional().withArg("local_tun").
+//		nest().optional().nospace().withArg("remote_tun").
+//		build()
+//
+// describes `ssh [-w local_tun [remote_tun]]`. or makes the parameter a
+// choice between alternatives, starting the next one, so
+//
+//	newCmd("ssh-agent").syntax().
+//		flag("c").optional().or().withFlag("s").
+//		build()
+//
+// describes `ssh-agent [-c | -s]`.
+type cmdBuilder struct {
+	cmd      Command
+	syntaxes [][]*paramBuilder
+}
+
+type paramBuilder struct {
+	c      *cmdBuilder
+	p      Parameter
+	nested *paramBuilder
+	// The alternatives following this parameter, and for each of them
+	// the parameter they're an alternative to
+	alternatives []*paramBuilder
+	group        *paramBuilder
+}
+
+func newCmd(name string) *cmdBuilder {
+	return &cmdBuilder{cmd: Command{Name: name}}
+}
+
+// Set the command's description
+func (b *cmdBuilder) describe(description string) *cmdBuilder {
+	b.cmd.Description = description
+	return b
+}
+
+// Start a new syntax
+func (b *cmdBuilder) syntax() *cmdBuilder {
+	b.syntaxes = append(b.syntaxes, []*paramBuilder{})
+	return b
+}
+
+// Start a new parameter in the current syntax
+func (b *cmdBuilder) param() *paramBuilder {
+	if len(b.syntaxes) == 0 {
+		b.syntax()
+	}
+	pb := &paramBuilder{c: b}
+	last := len(b.syntaxes) - 1
+	b.syntaxes[last] = append(b.syntaxes[last], pb)
+	return pb
+}
+
+// Start a new parameter with the given flags
+func (b *cmdBuilder) flag(flags ...string) *paramBuilder {
+	return b.param().withFlag(flags...)
+}
+
+// Start a new positional argument
+func (b *cmdBuilder) arg(name string) *paramBuilder {
+	return b.param().withArg(name)
+}
+
+// Start a new literal keyword
+func (b *cmdBuilder) keyword(keyword string) *paramBuilder {
+	return b.param().withKeyword(keyword)
+}
+
+func (b *cmdBuilder) build() Command {
+	cmd := b.cmd
+	cmd.Syntaxes = []Syntax{}
+	for _, params := range b.syntaxes {
+		syn := Syntax{Parameters: []Parameter{}}
+		for _, pb := range params {
+			syn.Parameters = append(syn.Parameters, pb.parameter())
+		}
+		cmd.Syntaxes = append(cmd.Syntaxes, syn)
+	}
+	return cmd
+}
+
+func (pb *paramBuilder) withFlag(flags ...string) *paramBuilder {
+	pb.p.HasFlags = true
+	pb.p.Flags = flags
+	return pb
+}
+
+// Give the parameter an argument, which is taken by its last flag if
+// it has any
+func (pb *paramBuilder) withArg(name string) *paramBuilder {
+	pb.p.HasArgument = true
+	pb.p.Argument = name
+	if len(pb.p.Flags) > 0 {
+		pb.p.ArgumentFlag = pb.p.Flags[len(pb.p.Flags)-1]
+	}
+	return pb
+}
+
+func (pb *paramBuilder) withKeyword(keyword string) *paramBuilder {
+	pb.p.Keyword = keyword
+	return pb
+}
+
+func (pb *paramBuilder) optional() *paramBuilder {
+	pb.p.Optional = true
+	return pb
+}
+
+func (pb *paramBuilder) nospace() *paramBuilder {
+	pb.p.NoSpace = true
+	return pb
+}
+
+func (pb *paramBuilder) variadic() *paramBuilder {
+	pb.p.Variadic = true
+	return pb
+}
+
+func (pb *paramBuilder) countable() *paramBuilder {
+	pb.p.Countable = true
+	return pb
+}
+
+// Start a parameter nested inside this one
+func (pb *paramBuilder) nest() *paramBuilder {
+	pb.nested = &paramBuilder{c: pb.c}
+	return pb.nested
+}
+
+// Start an alternative to the parameter, so that exactly one of them is
+// given. Whether the choice is optional is set on the first parameter.
+func (pb *paramBuilder) or() *paramBuilder {
+	group := pb
+	if pb.group != nil {
+		group = pb.group
+	}
+	alt := &paramBuilder{c: pb.c, group: group}
+	group.alternatives = append(group.alternatives, alt)
+	return alt
+}
+
+// Start a new parameter with the given flags
+func (pb *paramBuilder) flag(flags ...string) *paramBuilder {
+	return pb.c.flag(flags...)
+}
+
+// Start a new positional argument
+func (pb *paramBuilder) arg(name string) *paramBuilder {
+	return pb.c.arg(name)
+}
+
+// Start a new literal keyword
+func (pb *paramBuilder) keyword(keyword string) *paramBuilder {
+	return pb.c.keyword(keyword)
+}
+
+func (pb *paramBuilder) syntax() *cmdBuilder {
+	return pb.c.syntax()
+}
+
+func (pb *paramBuilder) build() Command {
+	return pb.c.build()
+}
+
+// The parameter built so far, including any nested parameter
+func (pb *paramBuilder) parameter() Parameter {
+	p := pb.p
+	if pb.nested != nil {
+		nested := pb.nested.parameter()
+		p.HasParameter = true
+		p.Parameter = &nested
+	}
+	if len(pb.alternatives) > 0 {
+		first := p
+		first.Optional = false
+		p = Parameter{Optional: pb.p.Optional, Alternatives: []Parameter{first}}
+		for _, alt := range pb.alternatives {
+			p.Alternatives = append(p.Alternatives, alt.parameter())
+		}
+	}
+	return p
+}
+
+func TestBuilderMatchesParser(t *testing.T) {
+	tests := []struct {
+		synopsis string
+		want     Command
+	}{
+		{
+			".Op Fl a\n.Op Ar file ...",
+			newCmd("foo").syntax().
+				flag("a").optional().
+				arg("file").optional().variadic().
+				build(),
+		},
+		{
+			".Op Fl w Ar local_tun Ns Op : Ns Ar remote_tun",
+			newCmd("foo").syntax().
+				flag("w").optional().withArg("local_tun").
+				nest().optional().nospace().withArg("remote_tun").
+				build(),
+		},
+		{
+			".Op Fl c | s\n.Nm foo\n.Cm commit\n.Ar msg",
+			newCmd("foo").syntax().
+				flag("c").optional().or().withFlag("s").
+				syntax().
+				keyword("commit").
+				arg("msg").
+				build(),
+		},
+	}
+	for _, test := range tests {
+		c, err := ParseManText(mdocPage(test.synopsis))
+		if err != nil {
+			t.Errorf("%q: %v", test.synopsis, err)
+			continue
+		}
+		got := Command{Name: c.Name, Syntaxes: c.Syntaxes}
+		if d := Diff(got, test.want); d != "" {
+			t.Errorf("%q: %s", test.synopsis, d)
+		}
+	}
+}
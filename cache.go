@@ -0,0 +1,136 @@
+package kgo
+
+import (
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sync"
+)
+
+// A command parsed from a file, and what the file looked like then
+type cacheEntry struct {
+	ModTime int64 `json:"modtime"`
+	Size    int64 `json:"size"`
+	// The page a .so stub named, and what it looked like then
+	Source        string `json:"source,omitempty"`
+	SourceModTime int64  `json:"sourcemodtime,omitempty"`
+	SourceSize    int64  `json:"sourcesize,omitempty"`
+	Strict        bool   `json:"strict,omitempty"`
+	// The synopsis macros the page was parsed with, nil for the default
+	// set
+	Macros  []string `json:"macros,omitempty"`
+	Command Command  `json:"command"`
+}
+
+// Commands parsed on earlier runs, keyed by the absolute path of their
+// file, so files which haven't changed don't need parsing again. A file
+// has changed if its modification time or size is different, or those of
+// the page it names with .so are, and is parsed again if the options
+// affecting the result are different. Only pages which parsed are kept;
+// failures are always parsed again.
+type parseCache struct {
+	path    string
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+	dirty   bool
+}
+
+// Load the cache from a JSON file, starting an empty one if the file
+// doesn't exist yet
+func loadCache(path string) (*parseCache, error) {
+	c := &parseCache{path: path, entries: map[string]cacheEntry{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &c.entries); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Parse the file, or return the command from the cache if the file
+// hasn't changed since it was cached
+func (c *parseCache) parse(path string, opts ParseOptions) (Command, error) {
+	abs, err := filepath.Abs(path)
+	if err != nil {
+		return manfileToCommand(path, opts)
+	}
+	info, err := os.Stat(abs)
+	if err != nil {
+		return manfileToCommand(path, opts)
+	}
+
+	c.mu.Lock()
+	entry, ok := c.entries[abs]
+	c.mu.Unlock()
+	if ok && entry.ModTime == info.ModTime().UnixNano() && entry.Size == info.Size() &&
+		entry.Strict == opts.Strict && slices.Equal(entry.Macros, opts.macros) && entry.sourceUnchanged() {
+		return entry.Command, nil
+	}
+
+	command, source, err := parseManfile(abs, opts)
+	if err != nil {
+		return command, err
+	}
+	entry = cacheEntry{
+		ModTime: info.ModTime().UnixNano(),
+		Size:    info.Size(),
+		Strict:  opts.Strict,
+		Macros:  opts.macros,
+		Command: command,
+	}
+	if source != abs {
+		sourceInfo, err := os.Stat(source)
+		if err != nil {
+			return command, nil
+		}
+		entry.Source = source
+		entry.SourceModTime = sourceInfo.ModTime().UnixNano()
+		entry.SourceSize = sourceInfo.Size()
+	}
+	c.mu.Lock()
+	c.entries[abs] = entry
+	c.dirty = true
+	c.mu.Unlock()
+	return command, nil
+}
+
+// Whether the page a .so stub named, if it named one, is as it was when
+// the entry was cached
+func (e cacheEntry) sourceUnchanged() bool {
+	if e.Source == "" {
+		return true
+	}
+	info, err := os.Stat(e.Source)
+	return err == nil && e.SourceModTime == info.ModTime().UnixNano() && e.SourceSize == info.Size()
+}
+
+// Write the cache back to its file if anything was added. The file is
+// replaced in one step so an interrupted run can't leave it truncated.
+func (c *parseCache) save() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.dirty {
+		return nil
+	}
+	data, err := json.Marshal(c.entries)
+	if err != nil {
+		return err
+	}
+	tmp := c.path + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, c.path); err != nil {
+		return err
+	}
+	c.dirty = false
+	return nil
+}
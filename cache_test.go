@@ -0,0 +1,82 @@
+package kgo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// Mark the cached command for path, so a hit can be told from a miss
+func markCached(t *testing.T, c *parseCache, path string) {
+	t.Helper()
+	abs, _ := filepath.Abs(path)
+	entry, ok := c.entries[abs]
+	if !ok {
+		t.Fatalf("%s wasn't cached", path)
+	}
+	entry.Command.Description = "cached"
+	c.entries[abs] = entry
+}
+
+func TestCache(t *testing.T) {
+	dir := t.TempDir()
+	page := writePage(t, dir, "foo.1", mdocPage(".Op Fl a"))
+	stub := writePage(t, dir, "bar.1", ".so man1/foo.1\n")
+	c, err := loadCache(filepath.Join(dir, "cache.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parse := func(path string, opts ParseOptions) string {
+		t.Helper()
+		command, err := c.parse(path, opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return command.Description
+	}
+
+	// a miss parses the page and caches it, then a hit returns the entry
+	if got := parse(page, ParseOptions{}); got == "cached" {
+		t.Fatal("empty cache hit")
+	}
+	markCached(t, c, page)
+	if got := parse(page, ParseOptions{}); got != "cached" {
+		t.Error("unchanged page missed the cache")
+	}
+
+	// parsing with other macros, or after the page changes, misses
+	if got := parse(page, ParseOptions{macros: []string{".Op"}}); got == "cached" {
+		t.Error("hit with different macros")
+	}
+	markCached(t, c, page)
+	later := time.Now().Add(time.Hour)
+	if err := os.Chtimes(page, later, later); err != nil {
+		t.Fatal(err)
+	}
+	if got := parse(page, ParseOptions{macros: []string{".Op"}}); got == "cached" {
+		t.Error("hit after the modification time changed")
+	}
+
+	// a stub misses once the page it names changes
+	parse(stub, ParseOptions{})
+	markCached(t, c, stub)
+	if got := parse(stub, ParseOptions{}); got != "cached" {
+		t.Error("unchanged stub missed the cache")
+	}
+	writePage(t, dir, "foo.1", mdocPage(".Op Fl ab"))
+	if got := parse(stub, ParseOptions{}); got == "cached" {
+		t.Error("stub hit after the page it names changed")
+	}
+
+	if err := c.save(); err != nil {
+		t.Fatal(err)
+	}
+	saved, err := loadCache(filepath.Join(dir, "cache.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(saved.entries) != 2 {
+		t.Errorf("saved %d entries, want 2", len(saved.entries))
+	}
+}
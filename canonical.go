@@ -0,0 +1,81 @@
+package kgo
+
+import (
+	"sort"
+	"strings"
+)
+
+// A normalized copy of the command, so that renderers and comparisons
+// see a consistent form however the page happened to be written. The
+// name is resolved from the page's descriptions when the synopsis only
+// used a bare .Nm, flags and arguments are trimmed and unescaped, aliases
+// are sorted and duplicate syntaxes are dropped, in subcommands too.
+func (c Command) Canonicalize() Command {
+	canon := c
+	canon.Name = strings.Trim(strings.TrimSpace(c.Name), "\"")
+	if canon.Name == "" && len(c.Descriptions) == 1 {
+		for name := range c.Descriptions {
+			canon.Name = name
+		}
+	}
+
+	canon.Syntaxes = []Syntax{}
+	seen := map[string]bool{}
+	for _, syn := range c.Syntaxes {
+		params := []Parameter{}
+		for _, p := range syn.Parameters {
+			params = append(params, p.canonicalize())
+		}
+		cs := Syntax{Parameters: params}
+		if key := cs.String(); !seen[key] {
+			seen[key] = true
+			canon.Syntaxes = append(canon.Syntaxes, cs)
+		}
+	}
+
+	if len(c.Subcommands) > 0 {
+		canon.Subcommands = []Command{}
+		for _, sub := range c.Subcommands {
+			canon.Subcommands = append(canon.Subcommands, sub.Canonicalize())
+		}
+	}
+	return canon
+}
+
+// A normalized deep copy of the parameter
+func (p Parameter) canonicalize() Parameter {
+	canon := p
+	canon.Argument = strings.TrimSpace(p.Argument)
+	canon.Flags = []string{}
+	for _, flag := range p.Flags {
+		canon.Flags = append(canon.Flags, canonicalFlag(flag))
+	}
+	if len(p.Aliases) > 0 {
+		canon.Aliases = []string{}
+		for _, alias := range p.Aliases {
+			canon.Aliases = append(canon.Aliases, canonicalFlag(alias))
+		}
+		sort.Strings(canon.Aliases)
+	}
+	if p.Parameter != nil {
+		nested := p.Parameter.canonicalize()
+		canon.Parameter = &nested
+	}
+	if len(p.Alternatives) > 0 {
+		canon.Alternatives = []Parameter{}
+		for _, alt := range p.Alternatives {
+			canon.Alternatives = append(canon.Alternatives, alt.canonicalize())
+		}
+	}
+	return canon
+}
+
+// Trim a flag and replace the roff escaped dash of a long option with a
+// plain one, so .Fl \-color and .Fl -color are the same flag
+func canonicalFlag(flag string) string {
+	flag = strings.TrimSpace(flag)
+	if strings.HasPrefix(flag, "\\-") {
+		flag = "-" + strings.TrimPrefix(flag, "\\-")
+	}
+	return flag
+}
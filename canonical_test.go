@@ -0,0 +1,52 @@
+package kgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCanonicalize(t *testing.T) {
+	c := Command{
+		Name: " foo ",
+		Syntaxes: []Syntax{
+			{Parameters: []Parameter{{HasFlags: true, Flags: []string{"\\-color"}, Aliases: []string{"-c", "-C"}}}},
+			{Parameters: []Parameter{{HasFlags: true, Flags: []string{"-color"}, Aliases: []string{"-C", "-c"}}}},
+		},
+		Subcommands: []Command{{
+			Name: "bar",
+			Syntaxes: []Syntax{
+				{Parameters: []Parameter{{HasArgument: true, Argument: " file "}}},
+				{Parameters: []Parameter{{HasArgument: true, Argument: "file"}}},
+			},
+		}},
+	}
+	canon := c.Canonicalize()
+	if canon.Name != "foo" {
+		t.Errorf("name %q, want foo", canon.Name)
+	}
+	if len(canon.Syntaxes) != 1 {
+		t.Fatalf("got %d syntaxes, want the duplicate dropped", len(canon.Syntaxes))
+	}
+	p := canon.Syntaxes[0].Parameters[0]
+	if !reflect.DeepEqual(p.Flags, []string{"-color"}) || !reflect.DeepEqual(p.Aliases, []string{"-C", "-c"}) {
+		t.Errorf("flags %q aliases %q, want [-color] [-C -c]", p.Flags, p.Aliases)
+	}
+	if sub := canon.Subcommands[0]; len(sub.Syntaxes) != 1 || sub.Syntaxes[0].Parameters[0].Argument != "file" {
+		t.Errorf("subcommand not canonicalized: %+v", sub)
+	}
+	if c.Syntaxes[0].Parameters[0].Aliases[0] != "-c" {
+		t.Error("canonicalizing changed the original")
+	}
+}
+
+func TestEqualCanonical(t *testing.T) {
+	a := Command{Name: "foo", Syntaxes: []Syntax{{Parameters: []Parameter{{HasFlags: true, Flags: []string{"\\-all"}}}}}}
+	b := Command{Name: "foo", Syntaxes: []Syntax{{Parameters: []Parameter{{HasFlags: true, Flags: []string{"-all"}}}}}}
+	if !a.Equal(b) {
+		t.Errorf("not equal: %s", Diff(a.Canonicalize(), b.Canonicalize()))
+	}
+	b.Syntaxes[0].Parameters[0].Flags = []string{"-any"}
+	if a.Equal(b) {
+		t.Error("equal with different flags")
+	}
+}
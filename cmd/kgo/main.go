@@ -0,0 +1,104 @@
+// Command kgo parses the SYNOPSIS sections of man pages and prints the
+// commands they describe
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/michaeltchapman/kgo"
+)
+
+func main() {
+	path := flag.String("path", "", "directory of man pages to parse, by default every section under each MANPATH directory")
+	rangeLower := flag.Int("range-lower", 0, "index of the first file in the directory to parse")
+	rangeUpper := flag.Int("range-upper", 0, "index after the last file in the directory to parse, 0 for the end")
+	format := flag.String("format", "text", "output format, json, yaml or text")
+	limit := flag.Int("limit", 0, "parse at most this many files, 0 for no limit")
+	stats := flag.Bool("stats", false, "print how many pages parsed and why others failed")
+	workers := flag.Int("workers", 0, "number of files to parse at once, 0 for one per CPU")
+	sorted := flag.Bool("sort", false, "sort each command's flags and syntaxes so the output is stable")
+	verbose := flag.Bool("v", false, "log pages which fail to parse to stderr")
+	cache := flag.String("cache", "", "JSON file caching parsed pages between runs, so unchanged pages aren't parsed again")
+	report := flag.Bool("report", false, "print how many commands accept each flag and what it most often means, instead of the commands")
+	flag.Parse()
+
+	opts := kgo.ParseOptions{Limit: *limit, Workers: *workers, CacheFile: *cache}
+	if *verbose {
+		opts.Logger = slog.New(slog.NewTextHandler(os.Stderr, nil))
+	}
+	if *path == "" {
+		*path = strings.Join(kgo.ManPaths(), string(os.PathListSeparator))
+		opts.Recursive = true
+	}
+	switch *format {
+	case "json", "yaml", "text":
+	default:
+		fmt.Fprintf(os.Stderr, "unknown format %q, expected json, yaml or text\n", *format)
+		os.Exit(2)
+	}
+
+	// kgo - parses a single page read from stdin
+	if flag.Arg(0) == "-" {
+		parseStdin(*format, *sorted)
+		return
+	}
+
+	commands, summary, err := kgo.ParseManFiles(*path, *rangeLower, *rangeUpper, opts)
+	if *report {
+		fmt.Print(kgo.FormatFlagReport(kgo.FlagFrequency(commands)))
+	} else {
+		for _, command := range commands {
+			if *sorted {
+				command = command.Sorted()
+			}
+			printCommand(command, *format)
+		}
+	}
+	if *stats {
+		fmt.Fprint(os.Stderr, summary)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+func parseStdin(format string, sorted bool) {
+	command, err := kgo.ParseReader(os.Stdin)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	if sorted {
+		command = command.Sorted()
+	}
+	printCommand(command, format)
+}
+
+// Print the command as text, as a line of JSON, or as a YAML document
+// starting with ---
+func printCommand(command kgo.Command, format string) {
+	switch format {
+	case "json":
+		out, err := json.Marshal(command)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Println(string(out))
+	case "yaml":
+		out, err := command.YAML()
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		fmt.Print("---\n" + out)
+	default:
+		fmt.Println(command)
+	}
+}
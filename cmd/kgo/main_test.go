@@ -0,0 +1,103 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/michaeltchapman/kgo"
+)
+
+// The output of parseStdin given the page on stdin
+func runStdin(t *testing.T, page string, format string) string {
+	t.Helper()
+	in := filepath.Join(t.TempDir(), "foo.1")
+	if err := os.WriteFile(in, []byte(page), 0644); err != nil {
+		t.Fatal(err)
+	}
+	f, err := os.Open(in)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	r, w, err := os.Pipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdin, stdout := os.Stdin, os.Stdout
+	os.Stdin, os.Stdout = f, w
+	defer func() { os.Stdin, os.Stdout = stdin, stdout }()
+
+	parseStdin(format, false)
+	w.Close()
+	out, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(out)
+}
+
+func TestParseStdin(t *testing.T) {
+	page := ".Dd January 1, 2024\n.Dt FOO 1\n.Os\n.Sh SYNOPSIS\n.Nm foo\n.Op Fl v\n.Ar file\n.Sh DESCRIPTION\nDoes things.\n"
+	want, err := kgo.ParseManText(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := runStdin(t, page, "text"); out != want.String()+"\n" {
+		t.Errorf("text got\n%s\nwant\n%s", out, want)
+	}
+	var got kgo.Command
+	if err := json.Unmarshal([]byte(runStdin(t, page, "json")), &got); err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(want) {
+		t.Errorf("json differs: %s", kgo.Diff(got, want))
+	}
+}
+
+// Run kgo with the given arguments in a child process, as main exits
+func runMain(t *testing.T, args ...string) (string, int) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestMainFlags$")
+	cmd.Env = append(os.Environ(), "KGO_RUN_MAIN=1", "KGO_ARGS="+strings.Join(args, "\n"))
+	out, err := cmd.Output()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) {
+		return string(out), exitErr.ExitCode()
+	} else if err != nil {
+		t.Fatal(err)
+	}
+	return string(out), 0
+}
+
+func TestMainFlags(t *testing.T) {
+	if os.Getenv("KGO_RUN_MAIN") == "1" {
+		os.Args = append([]string{"kgo"}, strings.Split(os.Getenv("KGO_ARGS"), "\n")...)
+		main()
+		os.Exit(0)
+	}
+	out, code := runMain(t, "-path", "../../testdata/man1", "-range-lower", "1", "-range-upper", "3", "-format", "json")
+	if code != 0 {
+		t.Fatalf("exit %d", code)
+	}
+	names := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(out), "\n") {
+		var c kgo.Command
+		if err := json.Unmarshal([]byte(line), &c); err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, c.Name)
+	}
+	if want := []string{"git-commit", "gzip"}; !reflect.DeepEqual(names, want) {
+		t.Errorf("got %q, want %q", names, want)
+	}
+	if _, code := runMain(t, "-path", "../../testdata/man1", "-format", "xml"); code != 2 {
+		t.Errorf("unknown format: exit %d, want 2", code)
+	}
+}
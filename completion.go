@@ -0,0 +1,317 @@
+package kgo
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// A flag as offered by the shell completion scripts
+type completionFlag struct {
+	// As typed on the command line, eg -o or --color
+	name string
+	// The argument the flag takes, if any
+	argument string
+	argtype  ArgType
+	// Whether the flag can be given more than once
+	repeatable bool
+	// A short description from the page, if it has one
+	description string
+}
+
+func (f completionFlag) takesArgument() bool {
+	return f.argument != ""
+}
+
+// Flags which can be written into a completion script, without spaces,
+// quotes, brackets or other characters a shell treats specially. Any
+// others, such as -squash) from a page the parser misread, are left out.
+var flagToken = regexp.MustCompile("^[^\\s'\"\\\\$`{}()<>|;&\\[\\]*]+$")
+
+// Every distinct flag the command accepts in any of its syntaxes, in the
+// order they first appear
+func completionFlags(c Command) []completionFlag {
+	flags := []completionFlag{}
+	seen := map[string]bool{}
+	for _, p := range c.allParameters() {
+		for _, flag := range p.flagList() {
+			cf := completionFlag{name: p.displayFlag(flag), repeatable: p.Countable}
+			cf.description = c.Options[cf.name].summary()
+			if seen[cf.name] || !flagToken.MatchString(cf.name) {
+				continue
+			}
+			seen[cf.name] = true
+			if p.takesArgument(flag) {
+				cf.argument = p.Argument
+				cf.argtype = p.argType()
+			}
+			flags = append(flags, cf)
+		}
+	}
+	return flags
+}
+
+// Every distinct positional argument of the command's syntaxes, in the
+// order they first appear
+func completionPositionals(c Command) []Parameter {
+	positionals := []Parameter{}
+	seen := map[string]bool{}
+	for _, syn := range c.Syntaxes {
+		for _, p := range syn.Parameters {
+			if p.HasArgument && !p.HasFlags && !seen[p.Argument] {
+				seen[p.Argument] = true
+				positionals = append(positionals, p)
+			}
+		}
+	}
+	return positionals
+}
+
+// The name of the shell function completing the command, which can only
+// contain identifier characters
+func completionFunction(c Command) string {
+	return "_" + nonIdentifier.ReplaceAllString(c.Name, "_")
+}
+
+// A bash completion script for the command, defining a completion
+// function and registering it with complete. Flags are offered when the
+// current word starts with a dash, the argument of a flag is completed
+// according to its type, and otherwise the command's first positional
+// argument is completed, defaulting to filenames.
+func BashCompletion(c Command) string {
+	fn := completionFunction(c)
+	flags := completionFlags(c)
+
+	ret := fmt.Sprintf("# bash completion for %s\n", c.Name)
+	ret = ret + fn + "()\n{\n"
+	ret = ret + "\tlocal cur prev\n"
+	ret = ret + "\tcur=\"${COMP_WORDS[COMP_CWORD]}\"\n"
+	ret = ret + "\tprev=\"${COMP_WORDS[COMP_CWORD-1]}\"\n\n"
+
+	// Group the flags taking an argument by how the argument is completed
+	actions := []string{}
+	byAction := map[string][]string{}
+	names := []string{}
+	for _, f := range flags {
+		names = append(names, f.name)
+		if !f.takesArgument() {
+			continue
+		}
+		action := bashAction(f.argtype)
+		if _, ok := byAction[action]; !ok {
+			actions = append(actions, action)
+		}
+		byAction[action] = append(byAction[action], f.name)
+	}
+	if len(actions) > 0 {
+		ret = ret + "\tcase \"$prev\" in\n"
+		for _, action := range actions {
+			patterns := []string{}
+			for _, name := range byAction[action] {
+				patterns = append(patterns, bashQuote(name))
+			}
+			ret = ret + "\t" + strings.Join(patterns, "|") + ")\n"
+			if action == "" {
+				ret = ret + "\t\tCOMPREPLY=()\n"
+			} else {
+				ret = ret + fmt.Sprintf("\t\tCOMPREPLY=( $(compgen %s -- \"$cur\") )\n", action)
+			}
+			ret = ret + "\t\treturn\n\t\t;;\n"
+		}
+		ret = ret + "\tesac\n\n"
+	}
+
+	if len(names) > 0 {
+		ret = ret + "\tif [[ \"$cur\" == [-+]* ]]; then\n"
+		ret = ret + fmt.Sprintf("\t\tCOMPREPLY=( $(compgen -W %s -- \"$cur\") )\n", bashQuote(strings.Join(names, " ")))
+		ret = ret + "\t\treturn\n\tfi\n\n"
+	}
+
+	action := "-f"
+	if positionals := completionPositionals(c); len(positionals) > 0 {
+		if a := bashAction(positionals[0].argType()); a != "" {
+			action = a
+		}
+	}
+	ret = ret + fmt.Sprintf("\tCOMPREPLY=( $(compgen %s -- \"$cur\") )\n", action)
+	ret = ret + "}\n"
+	ret = ret + fmt.Sprintf("complete -F %s %s\n", fn, bashQuote(c.Name))
+	return ret
+}
+
+// Quote a string for bash, in single quotes so nothing in it is expanded
+func bashQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+// Quote a message or action for an _arguments spec, which is written in
+// single quotes and uses colons as separators
+func zshQuote(s string) string {
+	s = strings.ReplaceAll(s, ":", "\\:")
+	return strings.ReplaceAll(s, "'", `'\''`)
+}
+
+// Escapes for the brackets around a flag's description
+var zshBrackets = strings.NewReplacer("[", "\\[", "]", "\\]")
+
+// The message and action completing an argument, eg file:_files. An
+// action of a single space shows the message without completing
+// anything.
+func zshArgument(name string, t ArgType) string {
+	action := zshAction(t)
+	if action == "" {
+		action = " "
+	}
+	return zshQuote(name) + ":" + action
+}
+
+// Returned for a command whose name can't be written into a completion
+// script
+var ErrCompletionName = errors.New("command name can't be completed")
+
+// A zsh completion function for the command, with one _arguments spec
+// per flag and positional argument, eg
+//
+//	#compdef ssh
+//
+//	_ssh() {
+//		_arguments \
+//			'-4[Forces ssh to use IPv4 addresses only.]' \
+//			'-i:identity_file:_files' \
+//			':destination:_hosts'
+//	}
+//
+//	_ssh "$@"
+//
+// Optional positional arguments are given with a double colon and
+// variadic ones with a star, as are flags which can be repeated. Flags
+// described by the page have the first sentence of their description in
+// brackets. The #compdef line can't quote the command's name, so a name
+// containing spaces or other characters special to the shell is an
+// error.
+func ZshCompletion(c Command) (string, error) {
+	if !flagToken.MatchString(c.Name) {
+		return "", fmt.Errorf("%w: %q", ErrCompletionName, c.Name)
+	}
+	fn := completionFunction(c)
+	specs := []string{}
+	for _, f := range completionFlags(c) {
+		spec := zshQuote(f.name)
+		if f.repeatable {
+			spec = "*" + spec
+		}
+		if f.description != "" {
+			spec = spec + "[" + zshBrackets.Replace(zshQuote(f.description)) + "]"
+		}
+		if f.takesArgument() {
+			spec = spec + ":" + zshArgument(f.argument, f.argtype)
+		}
+		specs = append(specs, spec)
+	}
+	for _, p := range completionPositionals(c) {
+		spec := ":"
+		if p.Variadic {
+			spec = "*:"
+		} else if p.Optional {
+			spec = "::"
+		}
+		specs = append(specs, spec+zshArgument(p.Argument, p.argType()))
+	}
+
+	ret := fmt.Sprintf("#compdef %s\n\n", c.Name)
+	ret = ret + fn + "() {\n\t_arguments"
+	for _, spec := range specs {
+		ret = ret + " \\\n\t\t'" + spec + "'"
+	}
+	ret = ret + "\n}\n\n"
+	ret = ret + fn + " \"$@\"\n"
+	return ret, nil
+}
+
+// The option naming the flag to fish's complete, eg -s 'o' for -o,
+// -l 'color' for --color or -o 'name' for old style long options like
+// -name
+func fishFlag(flag string) (string, bool) {
+	switch {
+	case strings.HasPrefix(flag, "--"):
+		return "-l " + fishQuote(strings.TrimPrefix(flag, "--")), true
+	case strings.HasPrefix(flag, "-") && len(flag) == 2:
+		return "-s " + fishQuote(flag[1:]), true
+	case strings.HasPrefix(flag, "-") && len(flag) > 2:
+		return "-o " + fishQuote(flag[1:]), true
+	}
+	return "", false
+}
+
+// Quote a string for fish, which only treats \\ and \' specially inside
+// single quotes
+func fishQuote(s string) string {
+	return "'" + strings.NewReplacer("\\", "\\\\", "'", "\\'").Replace(s) + "'"
+}
+
+// Fish completions for the command, one complete line per flag, eg
+//
+//	complete -c 'ssh' -s '4' -d 'Forces ssh to use IPv4 addresses only.'
+//	complete -c 'ssh' -s 'i' -r -F
+//
+// Flags taking an argument are marked -r and complete it according to
+// its type. Fish has no way to complete flags starting with a plus, so
+// they're left out.
+func FishCompletion(c Command) string {
+	ret := fmt.Sprintf("# fish completion for %s\n", c.Name)
+	for _, f := range completionFlags(c) {
+		name, ok := fishFlag(f.name)
+		if !ok {
+			continue
+		}
+		line := fmt.Sprintf("complete -c %s %s", fishQuote(c.Name), name)
+		if f.takesArgument() {
+			line = line + " -r " + fishAction(f.argtype)
+		}
+		if f.description != "" {
+			line = line + " -d " + fishQuote(f.description)
+		}
+		ret = ret + line + "\n"
+	}
+	return ret
+}
+
+// Quote a string for PowerShell, which doubles single quotes inside
+// single quotes
+func powerShellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", "''") + "'"
+}
+
+// A PowerShell argument completer for the command, offering each flag
+// as a CompletionResult, eg
+//
+//	Register-ArgumentCompleter -Native -CommandName 'ssh' -ScriptBlock {
+//		param($wordToComplete, $commandAst, $cursorPosition)
+//		@(
+//			[System.Management.Automation.CompletionResult]::new('-i', '-i', 'ParameterName', '-i identity_file')
+//		) | Where-Object { $_.CompletionText -like "$wordToComplete*" }
+//	}
+//
+// The tooltip shows the argument a flag takes, if any, followed by the
+// first sentence of its description.
+func PowerShellCompletion(c Command) string {
+	ret := fmt.Sprintf("Register-ArgumentCompleter -Native -CommandName %s -ScriptBlock {\n", powerShellQuote(c.Name))
+	ret = ret + "\tparam($wordToComplete, $commandAst, $cursorPosition)\n"
+	ret = ret + "\t@(\n"
+	for _, f := range completionFlags(c) {
+		// the tooltip can't be empty, so it at least repeats the flag
+		tip := f.name
+		if f.takesArgument() {
+			tip = tip + " " + f.argument
+		}
+		if f.description != "" {
+			tip = tip + ": " + f.description
+		}
+		name := powerShellQuote(f.name)
+		ret = ret + fmt.Sprintf("\t\t[System.Management.Automation.CompletionResult]::new(%s, %s, 'ParameterName', %s)\n", name, name, powerShellQuote(tip))
+	}
+	ret = ret + "\t) | Where-Object { $_.CompletionText -like \"$wordToComplete*\" }\n"
+	ret = ret + "}\n"
+	return ret
+}
@@ -0,0 +1,172 @@
+package kgo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestClassifyArgument(t *testing.T) {
+	tests := map[string]ArgType{
+		"host":        ArgHost,
+		"hostname":    ArgHost,
+		"destination": ArgFile,
+		"dest":        ArgFile,
+		"destdir":     ArgDirectory,
+		"login_name":  ArgUser,
+		"port":        ArgInteger,
+		"timeout":     ArgDuration,
+		"pattern":     ArgString,
+	}
+	for name, want := range tests {
+		if got := classifyArgument(name); got != want {
+			t.Errorf("%s: got %s, want %s", name, got, want)
+		}
+	}
+}
+
+func TestCompletionActions(t *testing.T) {
+	c, err := ParseManText(mdocPage(".Op Fl H Ar host\n.Op Fl u Ar user\n.Op Fl C Ar dir\n.Op Fl o Ar dest"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	zsh, err := ZshCompletion(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		shell string
+		out   string
+		want  []string
+	}{
+		{"bash", BashCompletion(c), []string{
+			"'-H')\n\t\tCOMPREPLY=( $(compgen -A hostname -- \"$cur\") )",
+			"'-u')\n\t\tCOMPREPLY=( $(compgen -A user -- \"$cur\") )",
+			"'-C')\n\t\tCOMPREPLY=( $(compgen -d -- \"$cur\") )",
+			"'-o')\n\t\tCOMPREPLY=( $(compgen -f -- \"$cur\") )",
+		}},
+		{"zsh", zsh, []string{"'-H:host:_hosts'", "'-u:user:_users'", "'-C:dir:_directories'", "'-o:dest:_files'"}},
+		{"fish", FishCompletion(c), []string{
+			"-s 'H' -r -f -a '(__fish_print_hostnames)'",
+			"-s 'u' -r -f -a '(__fish_complete_users)'",
+			"-s 'C' -r -f -a '(__fish_complete_directories)'",
+			"-s 'o' -r -F",
+		}},
+	}
+	for _, test := range tests {
+		for _, want := range test.want {
+			if !strings.Contains(test.out, want) {
+				t.Errorf("%s completion is missing %q:\n%s", test.shell, want, test.out)
+			}
+		}
+	}
+}
+
+// A command with a flag in both syntaxes, one taking a file and one
+// described in the page
+func completionCommand(t *testing.T) Command {
+	t.Helper()
+	page := mdocPage(".Op Fl v\n.Op Fl o Ar file\n.Nm foo\n.Fl v\n.Fl \\-list") + ".Bl -tag\n.It Fl v\nBe verbose. More so when repeated.\n.El\n"
+	c, err := ParseManText(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestBashCompletion(t *testing.T) {
+	out := BashCompletion(completionCommand(t))
+	for _, want := range []string{
+		"_foo()\n{",
+		"compgen -W '-v -o --list' -- \"$cur\"",
+		"'-o')\n\t\tCOMPREPLY=( $(compgen -f -- \"$cur\") )",
+		"complete -F _foo 'foo'\n",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q:\n%s", want, out)
+		}
+	}
+	if n := strings.Count(out, "-v"); n != 1 {
+		t.Errorf("-v offered %d times, want once:\n%s", n, out)
+	}
+	c := completionCommand(t)
+	c.Name = "my tool"
+	if out := BashCompletion(c); !strings.Contains(out, "complete -F _my_tool 'my tool'\n") {
+		t.Errorf("command name not quoted:\n%s", out)
+	}
+}
+
+func TestFishCompletion(t *testing.T) {
+	want := "# fish completion for foo\n" +
+		"complete -c 'foo' -s 'v' -d 'Be verbose.'\n" +
+		"complete -c 'foo' -s 'o' -r -F\n" +
+		"complete -c 'foo' -l 'list'\n"
+	if got := FishCompletion(completionCommand(t)); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	c := completionCommand(t)
+	c.Name = "it's mine"
+	if out := FishCompletion(c); !strings.Contains(out, "complete -c 'it\\'s mine' -s 'v'") {
+		t.Errorf("command name not quoted:\n%s", out)
+	}
+}
+
+func TestZshCompletion(t *testing.T) {
+	want := "#compdef foo\n\n" +
+		"_foo() {\n" +
+		"\t_arguments \\\n" +
+		"\t\t'-v[Be verbose.]' \\\n" +
+		"\t\t'-o:file:_files' \\\n" +
+		"\t\t'--list'\n" +
+		"}\n\n" +
+		"_foo \"$@\"\n"
+	got, err := ZshCompletion(completionCommand(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	for _, name := range []string{"my tool", "foo;rm", "$(foo)"} {
+		c := completionCommand(t)
+		c.Name = name
+		if _, err := ZshCompletion(c); !errors.Is(err, ErrCompletionName) {
+			t.Errorf("%q: got %v, want %v", name, err, ErrCompletionName)
+		}
+	}
+}
+
+func TestPowerShellCompletion(t *testing.T) {
+	out := PowerShellCompletion(completionCommand(t))
+	for _, want := range []string{
+		"Register-ArgumentCompleter -Native -CommandName 'foo' -ScriptBlock {\n",
+		"::new('-v', '-v', 'ParameterName', '-v: Be verbose.')\n",
+		"::new('-o', '-o', 'ParameterName', '-o file')\n",
+		"::new('--list', '--list', 'ParameterName', '--list')\n",
+		"Where-Object { $_.CompletionText -like \"$wordToComplete*\" }",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q:\n%s", want, out)
+		}
+	}
+	c, err := ParseManText(mdocPage(".Op Fl n") + ".Bl -tag\n.It Fl n\nDon't do it.\n.El\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := PowerShellCompletion(c); !strings.Contains(out, "'-n: Don''t do it.'") {
+		t.Errorf("quote not doubled:\n%s", out)
+	}
+}
+
+func TestClassifyUnits(t *testing.T) {
+	c, err := ParseManText(mdocPage(".Op Fl t Ar seconds\n.Op Fl w Ar timeout\n.Op Fl i Ar ms\n.Op Fl b Ar bytes\n.Op Fl s Ar block_size\n.Op Fl k Ar kb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []ArgType{ArgDuration, ArgDuration, ArgDuration, ArgByteSize, ArgByteSize, ArgByteSize}
+	for i, p := range c.Syntaxes[0].Parameters {
+		if got := p.argType(); got != want[i] {
+			t.Errorf("%s: got %s, want %s", p.Argument, got, want[i])
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package kgo
+
+import (
+	"encoding/json"
+)
+
+// A shell neutral description of how to complete a command, for
+// completion frameworks such as carapace or clap rather than a specific
+// shell. Serialized it looks like:
+//
+//	{
+//	  "name": "ssh",
+//	  "flags": [
+//	    {"short": "p", "takes_value": true, "value_type": "integer"},
+//	    {"long": "help"}
+//	  ],
+//	  "positionals": [
+//	    {"name": "destination", "type": "host"}
+//	  ],
+//	  "subcommands": []
+//	}
+//
+// short is the name of a flag used with a single dash, long the name of
+// one used with a double dash. A plus flag is given with a plus rather
+// than a dash, as in +compat. A repeatable flag can be given more than
+// once, as in -v -v -v. value_type and type are one of the ArgType
+// values. Subcommands are nested specs of the same shape.
+type CompletionSpec struct {
+	Name        string           `json:"name"`
+	Description string           `json:"description,omitempty"`
+	Flags       []SpecFlag       `json:"flags"`
+	Positionals []SpecPositional `json:"positionals"`
+	Subcommands []CompletionSpec `json:"subcommands"`
+}
+
+type SpecFlag struct {
+	Short       string  `json:"short,omitempty"`
+	Long        string  `json:"long,omitempty"`
+	Plus        bool    `json:"plus,omitempty"`
+	TakesValue  bool    `json:"takes_value,omitempty"`
+	Repeatable  bool    `json:"repeatable,omitempty"`
+	ValueType   ArgType `json:"value_type,omitempty"`
+	Description string  `json:"description,omitempty"`
+}
+
+type SpecPositional struct {
+	Name       string  `json:"name"`
+	Type       ArgType `json:"type"`
+	Optional   bool    `json:"optional,omitempty"`
+	Repeatable bool    `json:"repeatable,omitempty"`
+}
+
+// Build the completion spec for a command, merging the flags and
+// positional arguments of all its syntaxes. Those of syntaxes starting
+// with a subcommand's keywords go in that subcommand's spec instead.
+func (c Command) CompletionSpec() CompletionSpec {
+	spec := CompletionSpec{
+		Name:        c.Name,
+		Description: c.Description,
+		Flags:       []SpecFlag{},
+		Positionals: []SpecPositional{},
+		Subcommands: []CompletionSpec{},
+	}
+	seenFlags := map[string]bool{}
+	seenArgs := map[string]bool{}
+	for _, p := range c.ownParameters() {
+		if p.HasFlags {
+			for _, flag := range p.flagList() {
+				key := flag
+				if p.Plus {
+					key = "+" + flag
+				}
+				if seenFlags[key] {
+					continue
+				}
+				seenFlags[key] = true
+				sf := SpecFlag{Short: flag}
+				if long, ok := longOptionName(flag); ok {
+					sf = SpecFlag{Long: long}
+				}
+				sf.Plus = p.Plus
+				sf.Repeatable = p.Countable
+				if p.takesArgument(flag) {
+					sf.TakesValue = true
+					sf.ValueType = p.argType()
+				}
+				sf.Description = c.Options[p.displayFlag(flag)].summary()
+				spec.Flags = append(spec.Flags, sf)
+			}
+		} else if p.HasArgument && !seenArgs[p.Argument] {
+			seenArgs[p.Argument] = true
+			spec.Positionals = append(spec.Positionals, SpecPositional{
+				Name:       p.Argument,
+				Type:       p.argType(),
+				Optional:   p.Optional,
+				Repeatable: p.Variadic,
+			})
+		}
+	}
+	for _, sub := range c.Subcommands {
+		spec.Subcommands = append(spec.Subcommands, sub.CompletionSpec())
+	}
+	return spec
+}
+
+// The completion spec of the command serialized as JSON
+func (c Command) CompletionSpecJSON() ([]byte, error) {
+	return json.MarshalIndent(c.CompletionSpec(), "", "  ")
+}
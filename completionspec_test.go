@@ -0,0 +1,120 @@
+package kgo
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The fields each object in a completion spec may have, and whether
+// they're required, following the schema documented on CompletionSpec
+var specSchema = map[string]map[string]bool{
+	"spec":       {"name": true, "description": false, "flags": true, "positionals": true, "subcommands": true},
+	"flag":       {"short": false, "long": false, "plus": false, "takes_value": false, "repeatable": false, "value_type": false, "description": false},
+	"positional": {"name": true, "type": true, "optional": false, "repeatable": false},
+}
+
+var specTypes = map[ArgType]bool{
+	ArgString: true, ArgFile: true, ArgDirectory: true, ArgHost: true,
+	ArgUser: true, ArgInteger: true, ArgDuration: true, ArgByteSize: true,
+}
+
+// Check a decoded JSON object has only the fields the schema allows for
+// its kind, including those it requires
+func checkSpecFields(kind string, obj map[string]interface{}) error {
+	for field := range obj {
+		if _, ok := specSchema[kind][field]; !ok {
+			return fmt.Errorf("%s has unknown field %q", kind, field)
+		}
+	}
+	for field, required := range specSchema[kind] {
+		if _, ok := obj[field]; required && !ok {
+			return fmt.Errorf("%s is missing %q", kind, field)
+		}
+	}
+	return nil
+}
+
+// Check a decoded completion spec against the schema
+func checkSpec(v interface{}) error {
+	spec, ok := v.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("spec is %T, not an object", v)
+	}
+	if err := checkSpecFields("spec", spec); err != nil {
+		return err
+	}
+	for _, f := range spec["flags"].([]interface{}) {
+		flag := f.(map[string]interface{})
+		if err := checkSpecFields("flag", flag); err != nil {
+			return err
+		}
+		if flag["short"] == nil && flag["long"] == nil {
+			return fmt.Errorf("flag %v has no name", flag)
+		}
+		if t, ok := flag["value_type"].(string); ok && (flag["takes_value"] != true || !specTypes[ArgType(t)]) {
+			return fmt.Errorf("flag %v has a bad value_type", flag)
+		}
+	}
+	for _, p := range spec["positionals"].([]interface{}) {
+		positional := p.(map[string]interface{})
+		if err := checkSpecFields("positional", positional); err != nil {
+			return err
+		}
+		if t, _ := positional["type"].(string); !specTypes[ArgType(t)] {
+			return fmt.Errorf("positional %v has a bad type", positional)
+		}
+	}
+	for _, sub := range spec["subcommands"].([]interface{}) {
+		if err := checkSpec(sub); err != nil {
+			return fmt.Errorf("subcommand: %w", err)
+		}
+	}
+	return nil
+}
+
+func TestCompletionSpecSchema(t *testing.T) {
+	page := strings.Replace(mdocPage(".Op Fl v\n.Op Fl p Ar port\n.Ar host\n.Nm foo\n.Cm add\n.Op Fl \\-force\n.Ar file ..."), ".Sh SYNOPSIS", ".Sh NAME\n.Nm foo\n.Nd frobnicate things\n.Sh SYNOPSIS", 1) +
+		".Bl -tag\n.It Fl v\nBe verbose.\n.El\n"
+	c, err := ParseManText(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := c.CompletionSpecJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		t.Fatal(err)
+	}
+	if err := checkSpec(v); err != nil {
+		t.Errorf("%v in\n%s", err, data)
+	}
+
+	spec := c.CompletionSpec()
+	if spec.Description != "frobnicate things" {
+		t.Errorf("description %q, want %q", spec.Description, "frobnicate things")
+	}
+	want := []SpecFlag{
+		{Short: "v", Description: "Be verbose."},
+		{Short: "p", TakesValue: true, ValueType: ArgInteger},
+	}
+	if !reflect.DeepEqual(spec.Flags, want) {
+		t.Errorf("flags %+v, want %+v", spec.Flags, want)
+	}
+	if p := spec.Positionals; len(p) != 1 || p[0].Name != "host" {
+		t.Errorf("positionals %+v, want host", p)
+	}
+	if len(spec.Subcommands) != 1 || spec.Subcommands[0].Name != "add" {
+		t.Fatalf("subcommands %+v, want add", spec.Subcommands)
+	}
+	if f := spec.Subcommands[0].Flags; !reflect.DeepEqual(f, []SpecFlag{{Long: "force"}}) {
+		t.Errorf("add flags %+v, want --force", f)
+	}
+	if p := spec.Subcommands[0].Positionals; len(p) != 1 || p[0] != (SpecPositional{Name: "file", Type: ArgFile, Repeatable: true}) {
+		t.Errorf("add positionals %+v, want a repeatable file", p)
+	}
+}
@@ -0,0 +1,52 @@
+package kgo
+
+import (
+	"strings"
+)
+
+// Verbs commonly leading a command's description
+var actionVerbs = map[string]bool{
+	"add": true, "archive": true, "build": true, "change": true,
+	"check": true, "compare": true, "compress": true, "concatenate": true,
+	"configure": true, "control": true, "convert": true, "copy": true,
+	"count": true, "create": true, "decompress": true, "delete": true,
+	"display": true, "download": true, "dump": true, "edit": true,
+	"execute": true, "extract": true, "find": true, "format": true,
+	"generate": true, "get": true, "install": true, "kill": true,
+	"link": true, "list": true, "locate": true, "make": true,
+	"manage": true, "merge": true, "modify": true, "monitor": true,
+	"mount": true, "move": true, "print": true, "query": true,
+	"read": true, "remove": true, "rename": true, "report": true,
+	"run": true, "search": true, "send": true, "set": true,
+	"show": true, "sort": true, "split": true, "start": true,
+	"stop": true, "translate": true, "update": true, "verify": true,
+	"view": true, "wait": true, "write": true,
+}
+
+// The verb a command's description starts with, such as list for ls
+// ("list directory contents"), or empty when it doesn't obviously start
+// with one. Third person forms such as "lists" or "copies" are reduced
+// to the base verb.
+func (c Command) ActionVerb() string {
+	words := strings.Fields(strings.ToLower(c.Description))
+	if len(words) == 0 {
+		return ""
+	}
+	word := strings.Trim(words[0], ",.;:")
+	candidates := []string{word}
+	if strings.HasSuffix(word, "ies") {
+		candidates = append(candidates, strings.TrimSuffix(word, "ies")+"y")
+	}
+	if strings.HasSuffix(word, "es") {
+		candidates = append(candidates, strings.TrimSuffix(word, "es"))
+	}
+	if strings.HasSuffix(word, "s") {
+		candidates = append(candidates, strings.TrimSuffix(word, "s"))
+	}
+	for _, verb := range candidates {
+		if actionVerbs[verb] {
+			return verb
+		}
+	}
+	return ""
+}
@@ -0,0 +1,73 @@
+package kgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestActionVerb(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{"list directory contents", "list"},
+		{"copy files", "copy"},
+		{"remove directory entries", "remove"},
+		{"Copies files and directories", "copy"},
+		{"searches for a pattern", "search"},
+		{"OpenSSH remote login client", ""},
+		{"", ""},
+	}
+	for _, test := range tests {
+		if got := (Command{Description: test.description}).ActionVerb(); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.description, got, test.want)
+		}
+	}
+	c, err := ParseFile("testdata/man1/ls.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := c.ActionVerb(); got != "list" {
+		t.Errorf("ls: got %q, want list", got)
+	}
+}
+
+func TestDescriptions(t *testing.T) {
+	page := ".Dd January 1, 2024\n.Dt FOO 1\n.Os\n.Sh NAME\n.Nm foo\n.Nd do foo things\n.Nm bar ,\n.Nm baz\n.Nd do bar things\n" +
+		".Sh SYNOPSIS\n.Nm foo\n.Fl a\n.Nm bar\n.Fl b\n.Sh DESCRIPTION\nDoes things.\n"
+	c, err := ParseManText(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"foo": "do foo things", "bar": "do bar things", "baz": "do bar things"}
+	if !reflect.DeepEqual(c.Descriptions, want) {
+		t.Errorf("descriptions %q, want %q", c.Descriptions, want)
+	}
+	if c.Description != "do foo things" {
+		t.Errorf("description %q, want the first", c.Description)
+	}
+}
+
+func TestShortDescription(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{".Nd list directory contents", "list directory contents"},
+		{".Nd \"secure shell\" client\n(remote login program)", "secure shell client (remote login program)"},
+		{".Nd copy files\n.Sh SYNOPSIS", "copy files"},
+	}
+	for _, test := range tests {
+		lines := splitLines(".Sh NAME\n.Nm foo\n" + test.name + "\n")
+		if got := getDescription(lines); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.name, got, test.want)
+		}
+	}
+	c, err := ParseManText(mdocPage(".Fl a"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Description != "" {
+		t.Errorf("description %q without a .Nd, want none", c.Description)
+	}
+}
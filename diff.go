@@ -0,0 +1,174 @@
+package kgo
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Whether the two commands were parsed to the same thing once both are
+// canonicalized, so eg .Fl \-color and .Fl -color are equal. Empty and
+// missing lists or maps are treated the same.
+func (c Command) Equal(other Command) bool {
+	return Diff(c.Canonicalize(), other.Canonicalize()) == ""
+}
+
+// Describe the first difference between two commands, eg
+//
+//	syntax 1 parameter 2: flags [-a] != [-b]
+//
+// or return an empty string if they're the same. Useful for spotting a
+// page which changed between two releases of an OS.
+func Diff(a, b Command) string {
+	switch {
+	case a.Name != b.Name:
+		return fmt.Sprintf("name %q != %q", a.Name, b.Name)
+	case a.Section != b.Section:
+		return fmt.Sprintf("section %q != %q", a.Section, b.Section)
+	case a.Description != b.Description:
+		return fmt.Sprintf("description %q != %q", a.Description, b.Description)
+	case a.LowConfidence != b.LowConfidence:
+		return fmt.Sprintf("lowconfidence %v != %v", a.LowConfidence, b.LowConfidence)
+	case a.UnknownMacros != b.UnknownMacros:
+		return fmt.Sprintf("unknownmacros %d != %d", a.UnknownMacros, b.UnknownMacros)
+	case len(a.Syntaxes) != len(b.Syntaxes):
+		return fmt.Sprintf("syntax count %d != %d", len(a.Syntaxes), len(b.Syntaxes))
+	}
+
+	for i := range a.Syntaxes {
+		pa, pb := a.Syntaxes[i].Parameters, b.Syntaxes[i].Parameters
+		where := fmt.Sprintf("syntax %d", i+1)
+		if len(pa) != len(pb) {
+			return fmt.Sprintf("%s: parameter count %d != %d", where, len(pa), len(pb))
+		}
+		for j := range pa {
+			if d := diffParameter(pa[j], pb[j]); d != "" {
+				return fmt.Sprintf("%s parameter %d: %s", where, j+1, d)
+			}
+		}
+	}
+
+	names := []string{}
+	for name := range a.Descriptions {
+		names = append(names, name)
+	}
+	for name := range b.Descriptions {
+		names = append(names, name)
+	}
+	for _, name := range sortedUnique(names) {
+		if a.Descriptions[name] != b.Descriptions[name] {
+			return fmt.Sprintf("description of %s %q != %q", name, a.Descriptions[name], b.Descriptions[name])
+		}
+	}
+	flags := []string{}
+	for flag := range a.Options {
+		flags = append(flags, flag)
+	}
+	for flag := range b.Options {
+		flags = append(flags, flag)
+	}
+	for _, flag := range sortedUnique(flags) {
+		oa, ob := a.Options[flag], b.Options[flag]
+		if oa.Description != ob.Description || oa.Default != ob.Default || !equalStrings(oa.Requires, ob.Requires) {
+			return fmt.Sprintf("option %s %+v != %+v", flag, oa, ob)
+		}
+	}
+
+	if len(a.Subcommands) != len(b.Subcommands) {
+		return fmt.Sprintf("subcommand count %d != %d", len(a.Subcommands), len(b.Subcommands))
+	}
+	for i := range a.Subcommands {
+		if d := Diff(a.Subcommands[i], b.Subcommands[i]); d != "" {
+			return fmt.Sprintf("subcommand %s: %s", a.Subcommands[i].Name, d)
+		}
+	}
+	return ""
+}
+
+// The first field which differs between two parameters, or an empty
+// string if none do
+func diffParameter(a, b Parameter) string {
+	switch {
+	case a.Name != b.Name:
+		return fmt.Sprintf("name %q != %q", a.Name, b.Name)
+	case a.Optional != b.Optional:
+		return fmt.Sprintf("optional %v != %v", a.Optional, b.Optional)
+	case a.NoSpace != b.NoSpace:
+		return fmt.Sprintf("nospace %v != %v", a.NoSpace, b.NoSpace)
+	case a.HasArgument != b.HasArgument:
+		return fmt.Sprintf("hasargument %v != %v", a.HasArgument, b.HasArgument)
+	case a.Argument != b.Argument:
+		return fmt.Sprintf("argument %q != %q", a.Argument, b.Argument)
+	case a.Variadic != b.Variadic:
+		return fmt.Sprintf("variadic %v != %v", a.Variadic, b.Variadic)
+	case a.HasFlags != b.HasFlags:
+		return fmt.Sprintf("hasflags %v != %v", a.HasFlags, b.HasFlags)
+	case !equalStrings(a.Flags, b.Flags):
+		return fmt.Sprintf("flags %v != %v", a.Flags, b.Flags)
+	case !equalStrings(a.Aliases, b.Aliases):
+		return fmt.Sprintf("aliases %v != %v", a.Aliases, b.Aliases)
+	case a.ArgumentFlag != b.ArgumentFlag:
+		return fmt.Sprintf("argumentflag %q != %q", a.ArgumentFlag, b.ArgumentFlag)
+	case a.Countable != b.Countable:
+		return fmt.Sprintf("countable %v != %v", a.Countable, b.Countable)
+	case a.Keyword != b.Keyword:
+		return fmt.Sprintf("keyword %q != %q", a.Keyword, b.Keyword)
+	case a.Environment != b.Environment:
+		return fmt.Sprintf("environment %q != %q", a.Environment, b.Environment)
+	case a.Path != b.Path:
+		return fmt.Sprintf("path %q != %q", a.Path, b.Path)
+	case a.Variable != b.Variable:
+		return fmt.Sprintf("variable %q != %q", a.Variable, b.Variable)
+	case a.Stdin != b.Stdin:
+		return fmt.Sprintf("stdin %v != %v", a.Stdin, b.Stdin)
+	case a.Plus != b.Plus:
+		return fmt.Sprintf("plus %v != %v", a.Plus, b.Plus)
+	case a.HasParameter != b.HasParameter:
+		return fmt.Sprintf("hasparameter %v != %v", a.HasParameter, b.HasParameter)
+	case (a.Parameter == nil) != (b.Parameter == nil):
+		return fmt.Sprintf("parameter %v != %v", a.Parameter, b.Parameter)
+	case a.Choice != b.Choice:
+		return fmt.Sprintf("choice %v != %v", a.Choice, b.Choice)
+	case a.Placeholder != b.Placeholder:
+		return fmt.Sprintf("placeholder %v != %v", a.Placeholder, b.Placeholder)
+	case a.ArgumentLast != b.ArgumentLast:
+		return fmt.Sprintf("argumentlast %v != %v", a.ArgumentLast, b.ArgumentLast)
+	case len(a.Alternatives) != len(b.Alternatives):
+		return fmt.Sprintf("alternative count %d != %d", len(a.Alternatives), len(b.Alternatives))
+	}
+	if a.Parameter != nil {
+		if d := diffParameter(*a.Parameter, *b.Parameter); d != "" {
+			return "nested " + d
+		}
+	}
+	for i := range a.Alternatives {
+		if d := diffParameter(a.Alternatives[i], b.Alternatives[i]); d != "" {
+			return fmt.Sprintf("alternative %d: %s", i+1, d)
+		}
+	}
+	return ""
+}
+
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// The strings sorted with any duplicates removed, so differences are
+// reported in a stable order
+func sortedUnique(s []string) []string {
+	sort.Strings(s)
+	unique := []string{}
+	for i, v := range s {
+		if i == 0 || v != s[i-1] {
+			unique = append(unique, v)
+		}
+	}
+	return unique
+}
@@ -0,0 +1,36 @@
+package kgo
+
+import (
+	"testing"
+)
+
+func TestDiff(t *testing.T) {
+	parse := func(synopsis string) Command {
+		t.Helper()
+		c, err := ParseManText(mdocPage(synopsis))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	tests := []struct {
+		a, b string
+		want string
+	}{
+		{".Op Fl a", ".Op Fl a", ""},
+		{".Op Fl a\n.Ar file", ".Op Fl b\n.Ar file", "syntax 1 parameter 1: flags [a] != [b]"},
+		{".Op Fl a", ".Fl a", "syntax 1 parameter 1: optional true != false"},
+		{".Op Fl a", ".Op Fl a\n.Nm foo\n.Fl b", "syntax count 1 != 2"},
+		{".Op Fl o Ar file", ".Op Fl o Ar dir", "syntax 1 parameter 1: argument \"file\" != \"dir\""},
+	}
+	for _, test := range tests {
+		if got := Diff(parse(test.a), parse(test.b)); got != test.want {
+			t.Errorf("%q and %q: got %q, want %q", test.a, test.b, got, test.want)
+		}
+	}
+	a, b := parse(".Op Fl a"), parse(".Op Fl a")
+	b.Name = "bar"
+	if a.Equal(b) {
+		t.Errorf("%s equal to %s", a.Name, b.Name)
+	}
+}
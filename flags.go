@@ -0,0 +1,122 @@
+package kgo
+
+// The longest run of letters treated as a bundle of single letter flags
+const maxFlagBundle = 6
+
+// Split a flag token into the individual flags it stands for. Compact
+// synopses write .Fl abc meaning -a -b -c, while .Fl verbose is a single
+// flag, and nothing in the markup distinguishes the two. The heuristic
+// is to split only short tokens made up entirely of lowercase letters
+// (at most maxFlagBundle of them); anything longer, or containing
+// uppercase letters, digits or punctuation, is kept as one flag. In
+// particular numeric flags such as .Fl 1 (ls -1) are never split.
+func splitFlags(token string) []string {
+	if len(token) < 2 || len(token) > maxFlagBundle || isNumericFlag(token) {
+		return []string{token}
+	}
+	for _, r := range token {
+		if r < 'a' || r > 'z' {
+			return []string{token}
+		}
+	}
+	flags := []string{}
+	for _, r := range token {
+		flags = append(flags, string(r))
+	}
+	return flags
+}
+
+// The individual flags of the parameter. Plus flags are always words.
+func (p Parameter) flagList() []string {
+	if !p.HasFlags {
+		return []string{}
+	}
+	if p.Plus {
+		return p.Flags
+	}
+	flags := []string{}
+	for _, token := range p.Flags {
+		flags = append(flags, splitFlags(token)...)
+	}
+	// synonyms are whole words, and come after the flags they stand for
+	return append(flags, p.Aliases...)
+}
+
+// The individual flags of the parameter as typed on the command line,
+// eg -v, --color or +compat
+func (p Parameter) flagNames() []string {
+	names := []string{}
+	for _, flag := range p.flagList() {
+		names = append(names, p.displayFlag(flag))
+	}
+	return names
+}
+
+// One of the parameter's flags as typed on the command line
+func (p Parameter) displayFlag(flag string) string {
+	if p.Plus {
+		return "+" + flag
+	}
+	return flagName(flag)
+}
+
+// Whether the token is a flag given with a plus, eg +compat
+func isPlusFlag(token string) bool {
+	return len(token) > 1 && token[0] == '+'
+}
+
+// Whether the token is a single letter flag written several times over,
+// as in -vvv for increasing verbosity
+func isRepeatedFlag(token string) bool {
+	if len(token) < 2 {
+		return false
+	}
+	for i := 1; i < len(token); i++ {
+		if token[i] != token[0] {
+			return false
+		}
+	}
+	r := token[0]
+	return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z')
+}
+
+// The flag as it is typed on the command line, eg -v or --color
+func flagName(flag string) string {
+	if long, ok := longOptionName(flag); ok {
+		return "--" + long
+	}
+	return "-" + flag
+}
+
+// Whether the flag is made up only of digits, eg -1
+func isNumericFlag(token string) bool {
+	if token == "" {
+		return false
+	}
+	for _, r := range token {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Whether the flag takes the parameter's argument, as -o does in
+// .Fl o Ar file. In a bundle such as .Fl abc Ar file only the last flag
+// takes the argument.
+func (p Parameter) takesArgument(flag string) bool {
+	if !p.HasArgument || p.ArgumentFlag == "" {
+		return false
+	}
+	// a synonym for the flag taking the argument takes it too
+	for _, alias := range p.Aliases {
+		if flag == alias {
+			return true
+		}
+	}
+	if p.Plus {
+		return flag == p.ArgumentFlag
+	}
+	split := splitFlags(p.ArgumentFlag)
+	return flag == split[len(split)-1]
+}
@@ -0,0 +1,122 @@
+package kgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSplitFlags(t *testing.T) {
+	tests := []struct {
+		token string
+		want  []string
+	}{
+		{"a", []string{"a"}},
+		{"abc", []string{"a", "b", "c"}},
+		{"abcdef", []string{"a", "b", "c", "d", "e", "f"}},
+		// past maxFlagBundle letters it's a word
+		{"verbose", []string{"verbose"}},
+		{"abcdefg", []string{"abcdefg"}},
+		// anything but lowercase letters is one flag
+		{"aB", []string{"aB"}},
+		{"a1", []string{"a1"}},
+		{"-all", []string{"-all"}},
+	}
+	for _, test := range tests {
+		if got := splitFlags(test.token); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %q, want %q", test.token, got, test.want)
+		}
+	}
+	checkUsages(t, []usageTest{
+		{".Op Fl abc", []string{"foo [-a -b -c]"}},
+		{".Op Fl verbose", []string{"foo [-verbose]"}},
+	})
+}
+
+func TestNumericFlags(t *testing.T) {
+	c, err := ParseManText(mdocPage(".Op Fl 1\n.Op Fl 12\n.Op Fl \\-2"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{{"1"}, {"12"}, {"-2"}}
+	for i, p := range c.Syntaxes[0].Parameters {
+		if !reflect.DeepEqual(p.flagList(), want[i]) || p.HasArgument {
+			t.Errorf("parameter %d is %+v, want the flag %q", i+1, p, want[i])
+		}
+	}
+	if want := []string{"foo [-1] [-12] [--2]"}; !reflect.DeepEqual(c.Usage(), want) {
+		t.Errorf("usage %q, want %q", c.Usage(), want)
+	}
+	if got := c.OptString(); got != "1" {
+		t.Errorf("optstring %q, want 1", got)
+	}
+}
+
+func TestPlusFlags(t *testing.T) {
+	c, err := ParseManText(mdocPage(".Op Cm +compat\n.Op Li +x Ar file\n.Op Fl a\n.Ar +name"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := c.Syntaxes[0].Parameters
+	for i, want := range []string{"compat", "x"} {
+		if p := params[i]; !p.Plus || !p.HasFlags || !reflect.DeepEqual(p.Flags, []string{want}) || p.Keyword != "" {
+			t.Errorf("parameter %d is %+v, want the plus flag %s", i+1, p, want)
+		}
+	}
+	if p := params[3]; p.HasFlags || p.Argument != "+name" {
+		t.Errorf(".Ar +name is %+v, want an argument", p)
+	}
+	if want := []string{"foo [+compat] [+x file] [-a] <+name>"}; !reflect.DeepEqual(c.Usage(), want) {
+		t.Errorf("usage %q, want %q", c.Usage(), want)
+	}
+	if got := c.OptString(); got != "a" {
+		t.Errorf("optstring %q, want a", got)
+	}
+}
+
+func TestSeveralFlagsOnALine(t *testing.T) {
+	checkUsages(t, []usageTest{
+		{".Fl a b c", []string{"foo -a -b -c"}},
+		{".Op Fl a b c", []string{"foo [-a -b -c]"}},
+		{".Fl a b Ar file", []string{"foo -a -b file"}},
+	})
+	c, err := ParseManText(mdocPage(".Op Fl a b c"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.OptString(), "abc"; got != want {
+		t.Errorf("optstring %q, want %q", got, want)
+	}
+}
+
+func TestLongOptions(t *testing.T) {
+	checkUsages(t, []usageTest{
+		{".Op Fl \\-all", []string{"foo [--all]"}},
+		{".Op Fl -all", []string{"foo [--all]"}},
+		{".Op Fl \\-output Ar file", []string{"foo [--output file]"}},
+		{".Op Fl \\-color Ns = Ns Ar when", []string{"foo [--color=when]"}},
+	})
+	c, err := ParseManText(mdocPage(".Op Fl \\-output Ar file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := c.Syntaxes[0].Parameters[0]
+	if !reflect.DeepEqual(p.flagNames(), []string{"--output"}) || p.ArgumentFlag != "-output" {
+		t.Errorf("got %+v, want --output taking file", p)
+	}
+}
+
+func TestCommaSynonyms(t *testing.T) {
+	checkUsages(t, []usageTest{
+		{".Op Fl v , Fl \\-verbose", []string{"foo [-v | --verbose]"}},
+		{".Fl h , Fl \\-help", []string{"foo -h | --help"}},
+		{".Op Fl o , Fl \\-output Ar file", []string{"foo [-o | --output file]"}},
+	})
+	c, err := ParseManText(mdocPage(".Op Fl v , Fl \\-verbose"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := c.Syntaxes[0].Parameters[0]
+	if !reflect.DeepEqual(p.Flags, []string{"v"}) || !reflect.DeepEqual(p.Aliases, []string{"-verbose"}) {
+		t.Errorf("got flags %q aliases %q, want -v with the synonym --verbose", p.Flags, p.Aliases)
+	}
+}
@@ -0,0 +1,114 @@
+package kgo
+
+import (
+	"fmt"
+	"strings"
+)
+
+// mdoc writes long options as a flag starting with a dash, which may be
+// escaped, eg .Fl -color or .Fl \-color
+func longOptionName(flag string) (string, bool) {
+	for _, prefix := range []string{"\\-", "-"} {
+		if strings.HasPrefix(flag, prefix) {
+			return strings.TrimPrefix(flag, prefix), true
+		}
+	}
+	return "", false
+}
+
+// A struct option array for getopt_long covering each long option of
+// the command, terminated by the zeroed entry getopt_long expects. A
+// long option with a single letter synonym, as in -a | --all or an
+// .It Fl a , Fl \-all entry describing both, returns the letter, eg
+// { "all", no_argument, 0, 'a' }, so it's handled along with the short
+// option; any other returns 0.
+func (c Command) GetoptLongTable() string {
+	seen := map[string]bool{}
+	ret := "static struct option longopts[] = {\n"
+	for _, p := range c.allParameters() {
+		for _, flag := range p.flagList() {
+			name, ok := longOptionName(flag)
+			if !ok || name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			hasarg := "no_argument"
+			if p.takesArgument(flag) {
+				hasarg = "required_argument"
+			}
+			val := "0"
+			if short := c.shortFlag(p, flag); short != "" {
+				val = fmt.Sprintf("%q", rune(short[0]))
+			}
+			ret = ret + fmt.Sprintf("\t{ %q, %s, 0, %s },\n", name, hasarg, val)
+		}
+	}
+	return ret + "\t{ 0, 0, 0, 0 }\n};\n"
+}
+
+// The parameter's single letter flag, or "" if it has none. Bundled
+// flags such as -abc don't stand for any one of them, so they have none
+// either.
+func (p Parameter) shortFlag() string {
+	if p.Plus {
+		return ""
+	}
+	short := []string{}
+	for _, flag := range p.flagList() {
+		if len(flag) == 1 && flag != "-" {
+			short = append(short, flag)
+		}
+	}
+	if len(short) != 1 {
+		return ""
+	}
+	return short[0]
+}
+
+// The single letter synonym of one of the parameter's flags, either
+// from the parameter itself or from the entry describing the flag, or ""
+// if it has none
+func (c Command) shortFlag(p Parameter, flag string) string {
+	if short := p.shortFlag(); short != "" {
+		return short
+	}
+	if p.Plus {
+		return ""
+	}
+	short := ""
+	for _, synonym := range c.Options[p.displayFlag(flag)].Synonyms {
+		if len(synonym) != 2 || synonym == "--" {
+			continue
+		}
+		if short != "" {
+			return ""
+		}
+		short = synonym[1:]
+	}
+	return short
+}
+
+// A getopt optstring for the command's single letter flags, with a colon
+// after each one taking an argument, eg o:v for [-o file] [-v]. Long
+// options, plus flags and multi letter flags can't be expressed and are
+// left out.
+func (c Command) OptString() string {
+	seen := map[string]bool{}
+	ret := ""
+	for _, p := range c.allParameters() {
+		if p.Plus {
+			continue
+		}
+		for _, flag := range p.flagList() {
+			if len(flag) != 1 || flag == "-" || seen[flag] {
+				continue
+			}
+			seen[flag] = true
+			ret = ret + flag
+			if p.takesArgument(flag) {
+				ret = ret + ":"
+			}
+		}
+	}
+	return ret
+}
@@ -0,0 +1,48 @@
+package kgo
+
+import (
+	"testing"
+)
+
+func TestGetoptLongTable(t *testing.T) {
+	c, err := ParseManText(mdocPage(".Op Fl a , Fl \\-all\n.Op Fl \\-color Ns = Ns Ar when\n.Op Fl xy , Fl \\-xy"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "static struct option longopts[] = {\n" +
+		"\t{ \"all\", no_argument, 0, 'a' },\n" +
+		"\t{ \"color\", required_argument, 0, 0 },\n" +
+		"\t{ \"xy\", no_argument, 0, 0 },\n" +
+		"\t{ 0, 0, 0, 0 }\n};\n"
+	if got := c.GetoptLongTable(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+// The synopsis lists -a and --all apart but the entry describing them
+// pairs them up
+func TestGetoptLongTableOptions(t *testing.T) {
+	page := mdocPage(".Op Fl a\n.Op Fl \\-all\n.Op Fl \\-color") +
+		".Bl -tag\n.It Fl a , Fl \\-all\nShow everything.\n.It Fl \\-color\nUse colour.\n.El\n"
+	c, err := ParseManText(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "static struct option longopts[] = {\n" +
+		"\t{ \"all\", no_argument, 0, 'a' },\n" +
+		"\t{ \"color\", no_argument, 0, 0 },\n" +
+		"\t{ 0, 0, 0, 0 }\n};\n"
+	if got := c.GetoptLongTable(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestOptString(t *testing.T) {
+	c, err := ParseManText(mdocPage(".Op Fl v\n.Op Fl o Ar file\n.Op Fl \\-long\n.Op Fl abc\n.Nm foo\n.Fl v\n.Ar file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.OptString(), "vo:abc"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,55 @@
+package kgo
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata/golden with the parser's output")
+
+// Compare the output for a fixture with its golden file, or rewrite the
+// golden file with -update
+func checkGolden(t *testing.T, golden string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.WriteFile(golden, got, 0644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(golden)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(got) != string(want) {
+		t.Errorf("output differs from %s, rerun with -update if that's intended, got\n%s", golden, got)
+	}
+}
+
+func TestGolden(t *testing.T) {
+	fixtures, err := filepath.Glob("testdata/man1/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fixtures) == 0 {
+		t.Fatal("no fixtures in testdata/man1")
+	}
+	for _, fixture := range fixtures {
+		name := strings.TrimSuffix(strings.TrimSuffix(filepath.Base(fixture), ".gz"), ".1")
+		t.Run(name, func(t *testing.T) {
+			c, err := ParseFile(fixture)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := json.MarshalIndent(c, "", "  ")
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, filepath.Join("testdata/golden", name+".json"), append(got, '\n'))
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package kgo
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestGetHeaderUnescapes(t *testing.T) {
+	tests := []struct {
+		line           string
+		title, section string
+	}{
+		{`.TH "GIT\-COMMIT" "1" "2023\-08\-21"`, "GIT-COMMIT", "1"},
+		{`.TH foo\(hybar 8`, "foo-bar", "8"},
+		{`.TH x\&y\[aq]z 1`, "xy'z", "1"},
+		{`.Dt LS 1`, "LS", "1"},
+	}
+	for _, test := range tests {
+		title, section := getHeader([]string{test.line})
+		if title != test.title || section != test.section {
+			t.Errorf("%s: got %q %q, want %q %q", test.line, title, section, test.title, test.section)
+		}
+	}
+}
+
+func TestManNameInWords(t *testing.T) {
+	page := ".TH \"GIT\\-COMMIT\" \"1\"\n.SH SYNOPSIS\n.nf\n\\fIgit commit\\fR [\\-a] <file>\n.fi\n.SH DESCRIPTION\n"
+	c, err := ParseManText(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Name != "git-commit" {
+		t.Errorf("name %q, want git-commit", c.Name)
+	}
+	if want := []string{"git-commit [-a] <file>"}; !reflect.DeepEqual(c.Usage(), want) {
+		t.Errorf("usage %q, want %q", c.Usage(), want)
+	}
+}
+
+func TestQuotedName(t *testing.T) {
+	for _, synopsis := range []string{"\"my-tool\"", "\"my tool\""} {
+		page := ".Dd January 1, 2024\n.Os\n.Sh NAME\n.Nm " + synopsis + "\n.Nd does things\n.Sh SYNOPSIS\n.Nm\n.Fl a\n.Nm " + synopsis + " Fl b\n.Sh DESCRIPTION\nDoes things.\n"
+		c, err := ParseManText(page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		name := strings.Trim(synopsis, "\"")
+		if c.Name != name {
+			t.Errorf("name %q, want %q", c.Name, name)
+		}
+		if want := []string{name + " -a", name + " -b"}; !reflect.DeepEqual(c.Usage(), want) {
+			t.Errorf("usage %q, want %q", c.Usage(), want)
+		}
+	}
+}
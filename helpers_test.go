@@ -0,0 +1,30 @@
+package kgo
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// An mdoc page for foo with the given synopsis lines
+func mdocPage(synopsis string) string {
+	return ".Dd January 1, 2024\n.Dt FOO 1\n.Os\n.Sh SYNOPSIS\n.Nm foo\n" + synopsis + "\n.Sh DESCRIPTION\nDoes things.\n"
+}
+
+// A man(7) page with the given synopsis
+func manPage(synopsis string) string {
+	return ".TH FOO 1\n.SH SYNOPSIS\n" + synopsis + "\n.SH DESCRIPTION\nDoes things.\n"
+}
+
+// Write a page to man1 under dir, returning its path
+func writePage(t *testing.T, dir string, name string, text string) string {
+	t.Helper()
+	path := filepath.Join(dir, "man1", name)
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(text), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
@@ -0,0 +1,1808 @@
+package kgo
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+// Macros we can handle and understand
+var defaultMacros = []string{".Nm", ".Op", ".Oo", ".Ar", ".Fl", ".Ao", ".Aq", ".Brq", ".Bro", ".Cm", ".Ql", ".Li", ".Ev", ".Pa", ".Va"}
+
+// The synopsis macros a Parser understands unless it's given others
+func DefaultMacros() []string {
+	return append([]string{}, defaultMacros...)
+}
+
+// A command and the ways it can be invoked, as described by the
+// SYNOPSIS section of its man page
+type Command struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
+	// Pages documenting several tools describe each one separately
+	Descriptions map[string]string `json:"descriptions,omitempty"`
+	// What the page says about each flag, keyed by the flag as it's typed
+	Options map[string]Option `json:"options,omitempty"`
+	// The manual section from the page header, eg 1 or 8, or from the
+	// manN directory the page is in if the header doesn't give one
+	Section  string   `json:"section,omitempty"`
+	Syntaxes []Syntax `json:"syntaxes"`
+	// Commands nested under this one, such as commit under git
+	Subcommands []Command `json:"subcommands,omitempty"`
+	// Parsed by a best effort path rather than from mdoc macros
+	LowConfidence bool `json:"lowconfidence,omitempty"`
+	// Number of synopsis lines starting with a macro that isn't known
+	UnknownMacros int `json:"unknownmacros,omitempty"`
+}
+
+// One way of invoking a command, usually a single line of the synopsis
+type Syntax struct {
+	Parameters []Parameter `json:"parameters"`
+}
+
+// Options controlling how a set of man pages is parsed and filtered
+type ParseOptions struct {
+	// Drop commands whose syntaxes contain no flags or arguments, such
+	// as a synopsis consisting only of the command name
+	DropEmpty bool
+	// Fail on any macro in the synopsis that isn't understood rather than
+	// skipping the line
+	Strict bool
+	// Parse at most this many files, counted after any range is applied.
+	// Zero means no limit.
+	Limit int
+	// Parse the files in every directory under the path, such as all the
+	// sections of /usr/share/man, rather than just those in the path
+	Recursive bool
+	// Number of files to parse at once. Zero means one per CPU.
+	Workers int
+	// A JSON file caching the commands parsed from each file, so that
+	// files which haven't changed since the last run aren't parsed again.
+	// Empty means no cache.
+	CacheFile string
+	// Where to log pages which are skipped and other diagnostics. Nil
+	// means they're discarded.
+	Logger *slog.Logger
+	// The deepest parameters may be nested, eg two for .Op Fl a Op Fl b.
+	// Anything nested deeper is dropped with a warning. Zero means 32.
+	MaxDepth int
+
+	cache *parseCache
+	// The synopsis macros understood, set by a Parser. Nil means the
+	// default set.
+	macros []string
+}
+
+func (opts ParseOptions) logger() *slog.Logger {
+	if opts.Logger == nil {
+		return slog.New(slog.DiscardHandler)
+	}
+	return opts.Logger
+}
+
+func (opts ParseOptions) maxDepth() int {
+	if opts.MaxDepth <= 0 {
+		return maxParameterDepth
+	}
+	return opts.MaxDepth
+}
+
+func (opts ParseOptions) knownMacros() []string {
+	if opts.macros == nil {
+		return defaultMacros
+	}
+	return opts.macros
+}
+
+// Returned in strict mode when the synopsis uses a macro that isn't known
+var ErrUnknownMacro = errors.New("unknown macro")
+
+// A flag, argument or group of them within a syntax
+type Parameter struct {
+	Name        string   `json:"name,omitempty"`
+	Optional    bool     `json:"optional"`
+	NoSpace     bool     `json:"nospace"`
+	HasArgument bool     `json:"hasargument"`
+	Argument    string   `json:"argument,omitempty"`
+	Variadic    bool     `json:"variadic,omitempty"`
+	HasFlags    bool     `json:"hasflags"`
+	Flags       []string `json:"flags,omitempty"`
+	// Other spellings of the flags, such as the long option in
+	// .Fl v , Fl \-verbose
+	Aliases []string `json:"aliases,omitempty"`
+	// The flag taking the argument, eg o for .Fl o Ar file
+	ArgumentFlag string `json:"argumentflag,omitempty"`
+	Countable    bool   `json:"countable,omitempty"`
+	// A literal keyword such as a subcommand, given by .Cm, .Ql or .Li
+	Keyword string `json:"keyword,omitempty"`
+	// An environment variable given by .Ev, eg POSIXLY_CORRECT
+	Environment string `json:"environment,omitempty"`
+	// A literal file path given by .Pa, eg /etc/fstab
+	Path string `json:"path,omitempty"`
+	// SysV style flags given with a plus rather than a dash, eg +compat
+	Plus         bool       `json:"plus,omitempty"`
+	HasParameter bool       `json:"hasparameter"`
+	Parameter    *Parameter `json:"parameter,omitempty"`
+	// Mutually exclusive choices, exactly one of which is given, eg the
+	// two flags of .Op Fl a | Fl b
+	Alternatives []Parameter `json:"alternatives,omitempty"`
+	// A required group in braces, given by .Brq or .Bro, which is usually
+	// a choice between alternatives, eg {a | b}
+	Choice bool `json:"choice,omitempty"`
+	// The argument is a placeholder in angle brackets, given by .Aq or
+	// .Ao, eg <address>
+	Placeholder bool `json:"placeholder,omitempty"`
+	// The name of a variable the argument sets, given by .Va, eg name
+	// for .Fl D Va name Ns = Ns Ar value, whose argument is name=value
+	Variable string `json:"variable,omitempty"`
+	// A lone dash, given by .Fl on its own, which usually means reading
+	// from standard input
+	Stdin bool `json:"stdin,omitempty"`
+	// The argument comes after the nested group rather than before it,
+	// as port does in .Fl D Oo Ar bind_address : Oc Ns Ar port, in which
+	// case NoSpace means it's joined to the group
+	ArgumentLast bool `json:"argumentlast,omitempty"`
+}
+
+func getFileList(path string) ([]string, error) {
+	filepaths := []string{}
+	fileinfos, err := ioutil.ReadDir(path)
+
+	if err != nil {
+		return nil, fmt.Errorf("reading directory %s: %w", path, err)
+	}
+
+	for _, file := range fileinfos {
+		if !(file.IsDir()) {
+			filepaths = append(filepaths, path+"/"+file.Name())
+		}
+	}
+	return filepaths, nil
+}
+
+// A man page and the section of the manual it belongs to
+type ManFile struct {
+	Path    string
+	Section string
+}
+
+var sectionDirPattern = regexp.MustCompile(`^man(\w+)$`)
+
+// Every file at any depth under root, including the locale specific
+// directories such as man/fr/man1. Each file's section is taken from
+// the manN directory it's in, and is empty for files in other places.
+func getManFiles(root string) ([]ManFile, error) {
+	files := []ManFile{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		// MANPATH often lists directories that aren't there
+		if path == root && errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !d.IsDir() {
+			files = append(files, ManFile{Path: path, Section: sectionOf(path)})
+		}
+		return nil
+	})
+	return files, err
+}
+
+// Where man pages are installed when MANPATH isn't set
+const DefaultManPath = "/usr/share/man"
+
+// The directories to look for man pages in, from the MANPATH environment
+// variable, eg /usr/local/share/man:/usr/share/man, or the default when
+// it isn't set. As with man(1), an empty entry stands for the default.
+// Each directory is only given once, so /usr/share/man:: doesn't parse
+// every page three times.
+func ManPaths() []string {
+	manpath := os.Getenv("MANPATH")
+	if manpath == "" {
+		return []string{DefaultManPath}
+	}
+	paths := []string{}
+	seen := map[string]bool{}
+	for _, path := range strings.Split(manpath, string(os.PathListSeparator)) {
+		if path == "" {
+			path = DefaultManPath
+		}
+		if path = filepath.Clean(path); !seen[path] {
+			seen[path] = true
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// The section of the man page at path, from its manN directory
+func sectionOf(path string) string {
+	if m := sectionDirPattern.FindStringSubmatch(filepath.Base(filepath.Dir(path))); m != nil {
+		return m[1]
+	}
+	return ""
+}
+
+// Parse every man page in a directory, or when recursive in
+// every directory under it. A recursive path may list several roots
+// separated by colons in the same way as MANPATH. The range, if given,
+// selects the files to parse by their index in the directory listing,
+// with an upper bound of zero meaning the end of the listing. Pages are
+// parsed concurrently but the commands are returned in the order they're
+// listed. Pages that fail to parse are skipped, and tallied in the
+// returned stats.
+func ParseManFiles(path string, rangeLower int, rangeUpper int, opts ParseOptions) ([]Command, Stats, error) {
+	stats := newStats()
+	commands := []Command{}
+	files := []string{}
+	if opts.Recursive {
+		for _, root := range filepath.SplitList(path) {
+			manfiles, err := getManFiles(root)
+			if err != nil {
+				return nil, stats, err
+			}
+			for _, manfile := range manfiles {
+				files = append(files, manfile.Path)
+			}
+		}
+	} else {
+		var err error
+		files, err = getFileList(path)
+		if err != nil {
+			return nil, stats, err
+		}
+	}
+
+	s, err := selectRange(files, rangeLower, rangeUpper)
+	if err != nil {
+		return nil, stats, err
+	}
+	if opts.Limit > 0 && len(s) > opts.Limit {
+		s = s[:opts.Limit]
+	}
+
+	if opts.CacheFile != "" {
+		cache, err := loadCache(opts.CacheFile)
+		if err != nil {
+			return nil, stats, fmt.Errorf("loading cache %s: %w", opts.CacheFile, err)
+		}
+		opts.cache = cache
+	}
+
+	log := opts.logger()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	for result := range parseFilesOrdered(ctx, s, opts) {
+		dropped := result.Err == nil && opts.DropEmpty && !hasArguments(result.Command)
+		stats.add(result, dropped)
+		switch {
+		case errors.Is(result.Err, ErrNoSynopsis):
+			log.Debug("skipping file without a synopsis", "path", result.Path)
+		case result.Err != nil:
+			log.Warn("skipping page", "path", result.Path, "err", result.Err)
+		case dropped:
+			log.Debug("dropping page without arguments", "path", result.Path)
+		default:
+			commands = append(commands, result.Command)
+		}
+	}
+	if opts.cache != nil {
+		if err := opts.cache.save(); err != nil {
+			return commands, stats, fmt.Errorf("saving cache %s: %w", opts.CacheFile, err)
+		}
+	}
+	return commands, stats, nil
+}
+
+// The files between the lower and upper indexes, checked against the
+// number of files so that a bad range is an error rather than a panic
+func selectRange(files []string, lower int, upper int) ([]string, error) {
+	if upper == 0 {
+		upper = len(files)
+	}
+	if lower < 0 || upper > len(files) || lower > upper {
+		return nil, fmt.Errorf("range %d:%d is out of bounds for %d files", lower, upper, len(files))
+	}
+	return files[lower:upper], nil
+}
+
+func manfileToCommand(path string, opts ParseOptions) (Command, error) {
+	command, _, err := parseManfile(path, opts)
+	return command, err
+}
+
+// Parse the man page at path, also returning the file it was read from,
+// which for a .so stub is the page the stub names
+func parseManfile(path string, opts ParseOptions) (Command, string, error) {
+	lines, source, err := loadManPage(path)
+	if err != nil {
+		return Command{}, "", err
+	}
+	command, err := linesToCommand(lines, opts)
+	if command.Section == "" {
+		command.Section = sectionOf(path)
+	}
+	return command, source, err
+}
+
+// The most .so redirects followed before giving up on finding the page
+const maxRedirects = 8
+
+// Returned when .so redirects lead back to a page already visited
+var ErrRedirectLoop = errors.New(".so redirect loop")
+
+// Load the lines of the man page at path, and the path they were loaded
+// from. Many pages are just a stub with a .so request naming the real
+// page, eg .so man1/gzip.1, in which case the real page is loaded
+// instead.
+func loadManPage(path string) ([]string, string, error) {
+	visited := map[string]bool{}
+	for {
+		if visited[path] || len(visited) > maxRedirects {
+			return nil, "", fmt.Errorf("%w at %s", ErrRedirectLoop, path)
+		}
+		visited[path] = true
+
+		lines, err := loadFileToLines(path)
+		if err != nil {
+			return nil, "", err
+		}
+		target, ok := getRedirect(lines)
+		if !ok {
+			return lines, path, nil
+		}
+		resolved, err := resolveRedirect(path, target)
+		if err != nil {
+			return nil, "", err
+		}
+		path = resolved
+	}
+}
+
+// The page named by a .so request starting the file, ignoring any blank
+// lines and comments before it
+func getRedirect(lines []string) (string, bool) {
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if line == "" || isCommentLine(line) {
+			continue
+		}
+		if strings.HasPrefix(line, ".so ") {
+			return strings.TrimSpace(strings.TrimPrefix(line, ".so ")), true
+		}
+		return "", false
+	}
+	return "", false
+}
+
+// Find the file a .so request in the page at path refers to. Targets are
+// relative to the man root, the parent of the page's manN directory, and
+// may only exist compressed.
+func resolveRedirect(path string, target string) (string, error) {
+	root := filepath.Dir(filepath.Dir(path))
+	candidate := filepath.Join(root, target)
+	if filepath.IsAbs(target) {
+		candidate = target
+	}
+	for _, p := range []string{candidate, candidate + ".gz"} {
+		if _, err := os.Stat(p); err == nil {
+			return p, nil
+		}
+	}
+	_, err := os.Stat(candidate)
+	return "", fmt.Errorf("following .so %s from %s: %w", target, path, err)
+}
+
+// Parse the man page at path
+func ParseFile(path string) (Command, error) {
+	return manfileToCommand(path, ParseOptions{})
+}
+
+// Parse a man page read from r, such as a pipe, rather than a file
+func ParseReader(r io.Reader) (Command, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return Command{}, err
+	}
+	return linesToCommand(lines, ParseOptions{})
+}
+
+// Parse the text of a whole man page, as it would be read from a file
+func ParseManText(text string) (Command, error) {
+	return linesToCommand(splitLines(text), ParseOptions{})
+}
+
+func linesToCommand(rawlines []string, opts ParseOptions) (Command, error) {
+	rawlines = stripComments(rawlines)
+	rawlines = stripConditionals(rawlines)
+	rawlines = joinBlocks(rawlines, "Xo", "Xc", "")
+	rawlines = joinBlocks(rawlines, "Oo", "Oc", "Op")
+	rawlines = joinBlocks(rawlines, "Bro", "Brc", "Brq")
+	rawlines = joinBlocks(rawlines, "Ao", "Ac", "Aq")
+	if !hasSynopsis(rawlines) {
+		return Command{}, ErrNoSynopsis
+	}
+	if isManFormat(rawlines) {
+		title, section := getHeader(rawlines)
+		name := commandName(title, "")
+		command, err := buildCommand(name, getManSynopsisLines(rawlines, name), opts)
+		command.Section = section
+		command.LowConfidence = true
+		return command, err
+	}
+	unknown := unknownMacros(getSynopsisSection(rawlines), opts.knownMacros())
+	if opts.Strict && len(unknown) > 0 {
+		return Command{}, fmt.Errorf("%w: %s", ErrUnknownMacro, unknown[0])
+	}
+	lines := getSynopsisLines(rawlines, opts.knownMacros())
+	lowconfidence := false
+	if len(lines) == 0 {
+		if literal := getLiteralSynopsisLines(rawlines); len(literal) > 0 {
+			lines = literal
+			lowconfidence = true
+		}
+	}
+	title, section := getHeader(rawlines)
+	command, err := buildCommand(commandName(title, getDefinedName(rawlines)), lines, opts)
+	command.Section = section
+	command.Description = getDescription(rawlines)
+	command.Descriptions = getDescriptions(rawlines)
+	command.Options = getOptions(rawlines, command.Name)
+	command = expandGenericOptions(command, rawlines, opts)
+	command.UnknownMacros = len(unknown)
+	command.LowConfidence = lowconfidence
+	return command, err
+}
+
+// Load the lines of the man page at path, decompressing it if it's
+// gzipped
+func loadFileToLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if strings.HasSuffix(path, ".gz") {
+		zr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("reading %s: %w", path, err)
+		}
+		defer zr.Close()
+		r = zr
+	}
+	lines, err := readLines(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", path, err)
+	}
+	return lines, nil
+}
+
+// Read the lines of a man page from r
+func readLines(r io.Reader) ([]string, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	return splitLines(string(data)), nil
+}
+
+// Split text into lines, dropping the carriage return of any CRLF line
+// endings and any byte order mark at the start, which some translated
+// pages have in front of their first macro
+func splitLines(text string) []string {
+	text = strings.TrimPrefix(text, "\ufeff")
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSuffix(line, "\r")
+	}
+	return lines
+}
+
+// Whether the whole line is a roff comment, eg .\" or '\" as well as
+// groff's .\#. A \" later in a line only comments out the rest of it so
+// the line is kept.
+func isCommentLine(line string) bool {
+	for _, prefix := range []string{".\\\"", "'\\\"", ".\\#"} {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// The lines with any comment lines removed
+func stripComments(lines []string) []string {
+	ret := []string{}
+	for _, line := range lines {
+		if !isCommentLine(line) {
+			ret = append(ret, line)
+		}
+	}
+	return ret
+}
+
+// The lines with roff conditionals unwrapped, as if every .if and .ie
+// condition were true. The body of an .if or .ie is kept, whether it
+// follows the condition on the same line, eg .if n .Op Fl x, or is a
+// block between \{ and \}. The .el branches are dropped.
+func stripConditionals(lines []string) []string {
+	ret := []string{}
+	// Depth of the .el block being dropped, if any
+	skip := 0
+	for _, line := range lines {
+		opens, closes := strings.Count(line, "\\{"), strings.Count(line, "\\}")
+		if skip > 0 {
+			skip += opens - closes
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) == 0 {
+			ret = append(ret, line)
+			continue
+		}
+		switch fields[0] {
+		case ".el":
+			skip = opens - closes
+			continue
+		case ".if", ".ie":
+			// drop the request and its condition, leaving the body
+			line = ""
+			if len(fields) > 2 {
+				line = strings.Join(fields[2:], " ")
+			}
+		}
+		if opens == 0 && closes == 0 {
+			ret = append(ret, line)
+			continue
+		}
+		line = strings.ReplaceAll(line, "\\{", "")
+		line = strings.TrimSpace(strings.ReplaceAll(line, "\\}", ""))
+		// \{\ continues the request onto the next line
+		line = strings.TrimSuffix(line, "\\")
+		if line != "" && line != "." {
+			ret = append(ret, line)
+		}
+	}
+	return ret
+}
+
+func quoteString(s string) string {
+	return "\"" + s + "\""
+}
+
+// Determine if this is a synopsis heading. Real pages write it in many
+// ways, eg .Sh SYNOPSIS, .SH "SYNOPSIS", .Sh Synopsis or with a comment or
+// stray space after the heading.
+func isSynopsisLine(line string) bool {
+	if i := strings.Index(line, "\\\""); i >= 0 {
+		line = line[:i]
+	}
+	fields := strings.Fields(line)
+	if len(fields) < 2 || !strings.EqualFold(fields[0], ".Sh") {
+		return false
+	}
+	heading := strings.Trim(strings.Join(fields[1:], " "), "\" ")
+	return strings.EqualFold(heading, "synopsis")
+}
+
+// The title and section from the page header, .Dt in mdoc or .TH in
+// man(7), eg .Dt LS 1 or .TH ls 1 "March 2020", with any escapes
+// replaced, so .TH GIT\-COMMIT 1 is GIT-COMMIT
+func getHeader(lines []string) (string, string) {
+	for _, line := range lines {
+		tokens := tokenize(line)
+		if len(tokens) < 2 || (tokens[0] != ".Dt" && tokens[0] != ".TH") {
+			continue
+		}
+		section := ""
+		if len(tokens) > 2 {
+			section = roffText(tokens[2])
+		}
+		return roffText(tokens[1]), section
+	}
+	return "", ""
+}
+
+// The name of the command, from the page title if it has one. Titles are
+// conventionally capitalised, so the spelling from .Nm is kept when it
+// only differs in case, and an all capital title is otherwise lowered.
+func commandName(title string, defined string) string {
+	if title == "" || strings.EqualFold(title, defined) {
+		return defined
+	}
+	if title == strings.ToUpper(title) {
+		return strings.ToLower(title)
+	}
+	return title
+}
+
+// Some man pages will define their name and use .Nm as shorthand.
+// Names can contain digits and punctuation (git-rebase, mysqld_safe, 7z)
+// and may be quoted, eg .Nm "my tool". Anything after the name, such as
+// a trailing comma or more macros, is ignored.
+func getDefinedName(lines []string) string {
+	re := regexp.MustCompile(`^\.Nm\s+(?:"([^"]+)"|([\w][\w.+-]*))(\s.*)?$`)
+	for _, line := range lines {
+		result := re.FindAllStringSubmatch(line, -1)
+		if len(result) > 0 {
+			name := result[0][1] + result[0][2]
+			if !callableMacros[name] && !transparentMacros[name] {
+				return name
+			}
+		}
+	}
+	return ""
+}
+
+// The one line description given by .Nd in the NAME section
+func getDescription(lines []string) string {
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".Nd ") {
+			return descriptionAt(lines, i)
+		}
+	}
+	return ""
+}
+
+// The text of the .Nd line at i, joined with any lines of plain text it
+// was wrapped onto
+func descriptionAt(lines []string, i int) string {
+	words := tokenize(lines[i])[1:]
+	for _, line := range lines[i+1:] {
+		if strings.TrimSpace(line) == "" || strings.HasPrefix(line, ".") || strings.HasPrefix(line, "'") {
+			break
+		}
+		words = append(words, tokenize(line)...)
+	}
+	return strings.Join(words, " ")
+}
+
+// The description of each name in the NAME section. A .Nd describes
+// every .Nm since the previous .Nd, so both separate .Nm/.Nd pairs and
+// several names sharing one description are handled.
+func getDescriptions(lines []string) map[string]string {
+	descriptions := map[string]string{}
+	names := []string{}
+	inName := false
+	for i, line := range lines {
+		if strings.HasPrefix(line, ".Sh") || strings.HasPrefix(line, ".SH") {
+			if inName {
+				break
+			}
+			inName = strings.Contains(strings.ToUpper(line), "NAME")
+			continue
+		}
+		if !inName {
+			continue
+		}
+		tokens := tokenize(line)
+		if len(tokens) > 1 && tokens[0] == ".Nm" {
+			names = append(names, strings.TrimRight(tokens[1], ","))
+		} else if len(tokens) > 1 && tokens[0] == ".Nd" {
+			for _, name := range names {
+				descriptions[name] = descriptionAt(lines, i)
+			}
+			names = []string{}
+		}
+	}
+	return descriptions
+}
+
+// Whether the line starts a new usage with .Nm, whatever follows it, eg
+// .Nm, .Nm ssh or .Nm Fl v
+func isNameLine(line string) bool {
+	tokens := tokenize(line)
+	return len(tokens) > 0 && tokens[0] == ".Nm"
+}
+
+func hasSynopsis(lines []string) bool {
+	for _, line := range lines {
+		if isSynopsisLine(line) {
+			return true
+		}
+	}
+	return false
+}
+
+// The raw lines of the synopsis section, excluding its heading
+func getSynopsisSection(lines []string) []string {
+	section := []string{}
+	inSynopsis := false
+	for _, line := range lines {
+		if isSynopsisLine(line) {
+			inSynopsis = true
+			continue
+		}
+		if inSynopsis {
+			if strings.HasPrefix(line, ".Sh") || strings.HasPrefix(line, ".SH") {
+				break
+			}
+			section = append(section, line)
+		}
+	}
+	return section
+}
+
+// Requests which only space out or keep together the lines of a
+// synopsis, and say nothing about the command
+var formattingRequests = map[string]bool{
+	".Pp": true, ".br": true, ".sp": true, ".Bk": true, ".Ek": true, ".Sm": true,
+}
+
+func isFormattingLine(line string) bool {
+	tokens := tokenize(line)
+	return len(tokens) > 0 && formattingRequests[tokens[0]]
+}
+
+// The macros starting any of the lines that aren't known macros, other
+// than formatting requests
+func unknownMacros(lines []string, known []string) []string {
+	unknown := []string{}
+	for _, line := range lines {
+		if !strings.HasPrefix(line, ".") || isFormattingLine(line) {
+			continue
+		}
+		macro := tokenize(line)[0]
+		if !isKnownMacro(macro, known) {
+			unknown = append(unknown, macro)
+		}
+	}
+	return unknown
+}
+
+func isKnownMacro(macro string, known []string) bool {
+	for _, k := range known {
+		if macro == k {
+			return true
+		}
+	}
+	return false
+}
+
+// Get all the lines below the synopsis heading which use any of the
+// known macros, grouped into one usage for each syntax. A name line
+// starts each usage, but some pages, eg rlogin, begin the synopsis
+// without one, so the first compliant line always starts the first
+// usage. The command's name is taken from .Dt and .Nm elsewhere, so
+// nothing here depends on finding it.
+func getSynopsisLines(lines []string, known []string) [][]string {
+	inSynopsis := false
+	synopsis := [][]string{}
+
+	for _, line := range lines {
+		// Find the start of the synopsis section which contains the arguments
+		if isSynopsisLine(line) {
+			inSynopsis = true
+			continue
+		}
+		// Add lines until we reach the next section
+		if inSynopsis {
+			if !(strings.HasPrefix(line, ".Sh") || strings.HasPrefix(line, ".SH")) {
+				// Formatting requests never start or add to a usage
+				if !isFormattingLine(line) && compliantLine(line, known) {
+					if isNameLine(line) || len(synopsis) == 0 {
+						synopsis = append(synopsis, []string{})
+					}
+					last := len(synopsis) - 1
+					synopsis[last] = append(synopsis[last], splitNameKeywords(line)...)
+				}
+			} else {
+				break
+			}
+		}
+	}
+	return synopsis
+}
+
+// Split the keywords given by .Cm straight after the name onto lines of
+// their own, so .Nm git Cm commit Op Fl m Ar msg reads as it would with
+// each macro on its own line, with commit a subcommand of git. Left on
+// one line the keyword would take the rest of it as its parameter.
+func splitNameKeywords(line string) []string {
+	tokens := tokenize(line)
+	if len(tokens) == 0 || tokens[0] != ".Nm" {
+		return []string{line}
+	}
+	i := 1
+	if i < len(tokens) && !callableMacros[tokens[i]] {
+		i++
+	}
+	ret := []string{joinTokens(tokens[:i])}
+	for i+1 < len(tokens) && tokens[i] == "Cm" && !callableMacros[tokens[i+1]] && (i+2 == len(tokens) || tokens[i+2] != "Ns") {
+		ret = append(ret, ".Cm "+joinTokens(tokens[i+1:i+2]))
+		i += 2
+	}
+	if len(ret) == 1 || i < len(tokens) && !callableMacros[tokens[i]] {
+		return []string{line}
+	}
+	if i < len(tokens) {
+		ret = append(ret, "."+joinTokens(tokens[i:]))
+	}
+	return ret
+}
+
+// Many synopsis sections are done using bold/italics rather than macros
+// Let's ignore them because who knows what the author was thinking
+func compliantLine(line string, known []string) bool {
+	for _, macro := range known {
+		if strings.Contains(line, macro) {
+			return true
+		}
+	}
+	return false
+}
+
+// Join a block spread over several lines, opened by the open macro and
+// closed by the close macro, into a single line so it's parsed as one
+// parameter. Blocks may nest. The open macro is replaced, or dropped if
+// the replacement is empty, and the close macro is dropped, eg
+//
+//	.Oo
+//	.Fl f Ar file
+//	.Oc
+//
+// becomes .Op Fl f Ar file when Oo is replaced with Op. A block opened
+// and closed on the same line is left as it is.
+func joinBlocks(lines []string, open string, close string, replacement string) []string {
+	ret := []string{}
+	for i := 0; i < len(lines); i++ {
+		tokens := tokenize(lines[i])
+		depth := blockDepth(tokens, open, close)
+		if depth <= 0 {
+			ret = append(ret, lines[i])
+			continue
+		}
+		for depth > 0 && i+1 < len(lines) && !strings.HasPrefix(lines[i+1], ".Sh") && !strings.HasPrefix(lines[i+1], ".SH") {
+			i++
+			// spacing inside the block isn't part of it
+			if isFormattingLine(lines[i]) {
+				continue
+			}
+			next := tokenize(lines[i])
+			depth += blockDepth(next, open, close)
+			tokens = append(tokens, next...)
+		}
+
+		joined := []string{}
+		for _, token := range tokens {
+			switch macroName(token) {
+			case open:
+				if replacement != "" {
+					joined = append(joined, replacement)
+				}
+			case close:
+			default:
+				joined = append(joined, macroName(token))
+			}
+		}
+		if len(joined) > 0 {
+			joined[0] = "." + joined[0]
+		}
+		ret = append(ret, joinTokens(joined))
+	}
+	return ret
+}
+
+// The macro a token names, without the dot starting a line, eg Oo for
+// .Oo. Punctuation such as an ellipsis is returned unchanged.
+func macroName(token string) string {
+	if len(token) > 1 && token[0] == '.' && unicode.IsLetter(rune(token[1])) {
+		return token[1:]
+	}
+	return token
+}
+
+// How many more blocks the tokens open than they close
+func blockDepth(tokens []string, open string, close string) int {
+	depth := 0
+	for _, token := range tokens {
+		switch macroName(token) {
+		case open:
+			depth++
+		case close:
+			depth--
+		}
+	}
+	return depth
+}
+
+// The line tokenize would split into the tokens, quoting any which
+// contain spaces
+func joinTokens(tokens []string) string {
+	quoted := []string{}
+	for _, token := range tokens {
+		if token == "" || strings.ContainsAny(token, " \t") {
+			token = quoteString(strings.ReplaceAll(token, "\"", "\"\""))
+		}
+		quoted = append(quoted, token)
+	}
+	return strings.Join(quoted, " ")
+}
+
+func buildCommand(name string, paramLines [][]string, opts ParseOptions) (Command, error) {
+	syntax := []Syntax{}
+	var err error
+	err = nil
+	for _, lineset := range paramLines {
+		syn, e := buildSyntax(lineset, opts)
+		if e != nil {
+			err = e
+		} else if isValidSyntax(syn) {
+			syntax = append(syntax, syn)
+		}
+	}
+	if len(syntax) == 0 && err == nil {
+		err = ErrNoSyntaxes
+	}
+	return Command{Name: name, Syntaxes: syntax, Subcommands: buildSubcommands(syntax)}, err
+}
+
+// Syntaxes starting with literal keywords, such as git commit or
+// git remote add, describe subcommands. Gather them into a tree of
+// subcommands each holding the syntaxes that follow its keywords.
+func buildSubcommands(syntaxes []Syntax) []Command {
+	subcommands := []Command{}
+	for _, syn := range syntaxes {
+		path := []string{}
+		for _, p := range syn.Parameters {
+			if !isSubcommandKeyword(p) {
+				break
+			}
+			path = append(path, p.Keyword)
+		}
+		if len(path) > 0 {
+			rest := Syntax{Parameters: syn.Parameters[len(path):]}
+			subcommands = addSubcommand(subcommands, path, rest)
+		}
+	}
+	return subcommands
+}
+
+// A required keyword on its own
+func isSubcommandKeyword(p Parameter) bool {
+	return p.Keyword != "" && !p.Optional && !p.HasFlags && !p.HasArgument && !p.HasParameter
+}
+
+// Add the syntax to the subcommand at path, creating it and any parent
+// subcommands as needed
+func addSubcommand(subcommands []Command, path []string, syn Syntax) []Command {
+	i := 0
+	for i < len(subcommands) && subcommands[i].Name != path[0] {
+		i++
+	}
+	if i == len(subcommands) {
+		subcommands = append(subcommands, Command{Name: path[0], Syntaxes: []Syntax{}})
+	}
+	if len(path) > 1 {
+		subcommands[i].Subcommands = addSubcommand(subcommands[i].Subcommands, path[1:], syn)
+	} else if isValidSyntax(syn) {
+		subcommands[i].Syntaxes = append(subcommands[i].Syntaxes, syn)
+	}
+	return subcommands
+}
+
+func buildSyntax(lines []string, opts ParseOptions) (Syntax, error) {
+	parameters := []Parameter{}
+	var err error
+	err = nil
+	for i := 0; i < len(lines); i++ {
+		param, e := buildParameter(tokenize(lines[i]), opts)
+		if e != nil {
+			err = e
+		} else if isValidParameter(param) {
+			// A flag wrapped onto the end of one line may take the
+			// argument starting the next
+			if i+1 < len(lines) && takesContinuedArgument(param, lines[i+1]) {
+				next, e := buildParameter(tokenize(lines[i+1]), opts)
+				if e == nil && next.HasArgument {
+					param.HasArgument = true
+					param.ArgumentFlag = param.Flags[len(param.Flags)-1]
+					param.Argument = next.Argument
+					param.Variadic = next.Variadic
+					i++
+				}
+			}
+			parameters = append(parameters, param)
+		}
+	}
+	return Syntax{Parameters: parameters}, err
+}
+
+// Whether a flag is left waiting for an argument given on the next line.
+// Only a bare flag outside of any optional group can be continued, and
+// only by a line starting with the argument.
+func takesContinuedArgument(p Parameter, next string) bool {
+	if !p.HasFlags || p.HasArgument || p.Optional || p.HasParameter {
+		return false
+	}
+	tokens := tokenize(next)
+	return len(tokens) > 0 && tokens[0] == ".Ar" && !isNameLine(next)
+}
+
+// Split a synopsis line into macro tokens. Some pages separate macros
+// with tabs rather than spaces so split on any whitespace. As in roff, a
+// double quoted argument is a single token without its quotes, in which
+// a doubled quote stands for a literal one, eg .Ar "input file", and an
+// escaped space doesn't end a token.
+func tokenize(line string) []string {
+	tokens := []string{}
+	var token strings.Builder
+	inToken := false
+	quoted := false
+	for i := 0; i < len(line); i++ {
+		ch := line[i]
+		switch {
+		case ch == '\\' && i+1 < len(line) && line[i+1] == ' ':
+			// an escaped space doesn't separate arguments
+			inToken = true
+			token.WriteString("\\ ")
+			i++
+		case quoted && ch == '"' && i+1 < len(line) && line[i+1] == '"':
+			token.WriteByte('"')
+			i++
+		case quoted && ch == '"':
+			quoted = false
+		case !inToken && ch == '"':
+			inToken = true
+			quoted = true
+		case !quoted && (ch == ' ' || ch == '\t'):
+			if inToken {
+				tokens = append(tokens, token.String())
+				token.Reset()
+				inToken = false
+			}
+		default:
+			inToken = true
+			token.WriteByte(ch)
+		}
+	}
+	if inToken {
+		tokens = append(tokens, token.String())
+	}
+	return tokens
+}
+
+// Convert a string to an array of Parameters. The aggregate of these
+// will form a Syntax and the set of Syntaxes forms a command. Most
+// lines will only be a single parameter
+func buildParameter(rawtokens []string, opts ParseOptions) (Parameter, error) {
+	tokens := []string{}
+	for _, token := range rawtokens {
+		// tokens which were only markup, such as \&, disappear entirely
+		if clean := stripMarkup(token); clean != "" {
+			tokens = append(tokens, clean)
+		}
+	}
+	return buildAlternatives(dropEmptyNo(tokens), 0, opts)
+}
+
+// The parameter given by the tokens, or a group of alternatives if they
+// contain any
+func buildAlternatives(tokens []string, depth int, opts ParseOptions) (Parameter, error) {
+	branches := splitAlternatives(tokens)
+	if len(branches) < 2 {
+		return buildNestedParameter(tokens, depth, opts)
+	}
+	p := Parameter{}
+	for i, branch := range branches {
+		alt, err := buildNestedParameter(branch, depth, opts)
+		if err != nil {
+			return p, err
+		}
+		// The group as a whole is optional, as in .Op Fl a | Fl b, or
+		// in braces, as in .Brq Fl a | Fl b
+		if i == 0 {
+			p.Optional, p.Choice = alt.Optional, alt.Choice
+			alt.Optional, alt.Choice = false, false
+		}
+		if isValidParameter(alt) {
+			p.Alternatives = append(p.Alternatives, alt)
+		}
+	}
+	return p, nil
+}
+
+// .No ends the macro before it, so the text after it is plain rather
+// than the macro's value, eg text in .Fl x No text Ar file. A .No with
+// no text after it does nothing at all, as in .Fl T No Ns Ar term, so
+// it's dropped.
+func dropEmptyNo(tokens []string) []string {
+	ret := []string{}
+	for i, token := range tokens {
+		if token == "No" && (i+1 == len(tokens) || callableMacros[tokens[i+1]] || transparentMacros[tokens[i+1]]) {
+			continue
+		}
+		ret = append(ret, token)
+	}
+	return ret
+}
+
+// Split the tokens of a line at each | into the tokens of each
+// alternative. A branch starting with a plain word rather than a macro
+// continues the last macro of the branch before it, as mdoc does, so
+// .Fl c | s is -c or -s and .Ar host | user@host is two arguments.
+func splitAlternatives(tokens []string) [][]string {
+	branches := [][]string{}
+	branch := []string{}
+	macro := ""
+	// how many inline blocks are open, whose alternatives are their own
+	// rather than the line's. One starting the line, after any other
+	// groups as in .Op Bro, groups all of it.
+	open := 0
+	for i, token := range tokens {
+		switch macroName(token) {
+		case "Oo", "Bro":
+			if open > 0 || !leadingGroup(tokens, i) {
+				open++
+			}
+		case "Oc", "Brc":
+			if open > 0 {
+				open--
+			}
+		}
+		if token != "|" || open > 0 {
+			if name := macroName(token); callableMacros[name] {
+				// a group such as Aq starts the branch but isn't continued
+				if !groupMacros[name] {
+					macro = name
+				}
+			} else if len(branch) == 0 && len(branches) > 0 && macro != "" {
+				branch = append(branch, macro)
+			}
+			branch = append(branch, token)
+			continue
+		}
+		branches = append(branches, branch)
+		branch = []string{}
+	}
+	if len(branch) > 0 || len(branches) == 0 {
+		branches = append(branches, branch)
+	}
+	return branches
+}
+
+// The deepest level of nesting buildParameter will descend to unless
+// ParseOptions says otherwise, so malformed or adversarial lines can't
+// recurse without bound
+var maxParameterDepth = 32
+
+// Returned when a page has no synopsis lines that could be parsed
+var ErrNoSyntaxes = errors.New("no syntaxes found")
+
+// Returned for a file with no SYNOPSIS section at all, such as a README
+// or a page describing something other than a command, rather than one
+// whose synopsis couldn't be parsed
+var ErrNoSynopsis = errors.New("no synopsis section")
+
+func buildNestedParameter(tokens []string, depth int, opts ParseOptions) (Parameter, error) {
+	p := Parameter{}
+	var err error
+	err = nil
+
+	// Parse the tokens after i as a parameter nested in this one. They
+	// never include the token at i, so each level of nesting makes
+	// progress through the line. Past the depth limit the rest is
+	// dropped, keeping what was parsed above it.
+	nest := func(i int) *Parameter {
+		if depth >= opts.maxDepth() {
+			opts.logger().Warn("parameter nested too deeply, ignoring the rest", "limit", opts.maxDepth())
+			return nil
+		}
+		p.HasParameter = true
+		tp, e := buildAlternatives(tokens[i+1:blockEnd(tokens, i)], depth+1, opts)
+		if e != nil {
+			err = e
+			return nil
+		}
+		p.Parameter = &tp
+		return &tp
+	}
+
+	// the index of the first token after an inline block read as a
+	// nested group
+	skip := 0
+	for i, rawtoken := range tokens {
+		if i < skip {
+			continue
+		}
+		token := macroName(rawtoken)
+		// An inline block, eg Oo Ar bind_address : Oc, is the group it
+		// opens, ending at its close rather than the end of the line
+		if group, ok := inlineBlocks[token]; ok {
+			token = group.macro
+		}
+		// .Op and .Brq group what follows them. Starting the line they
+		// group the whole parameter, as in .Op Brq, and otherwise what
+		// follows is nested, belonging only to the nested group.
+		if token == "Op" || token == "Brq" {
+			if leadingGroup(tokens, i) {
+				p.Optional = p.Optional || token == "Op"
+				p.Choice = p.Choice || token == "Brq"
+				continue
+			}
+			if !p.HasParameter {
+				if nested := nest(i); nested != nil {
+					if token == "Op" {
+						nested.Optional = true
+					} else {
+						nested.Choice = true
+					}
+					// .Ns Op joins the nested group to what's before it
+					nested.NoSpace = i > 0 && tokens[i-1] == "Ns"
+				}
+			}
+			skip = blockEnd(tokens, i) + 1
+			continue
+		}
+		// .Ao and .Ac enclose an argument placeholder in angle brackets,
+		// eg .Ao Ar address Ac or just .Ao address Ac, as does .Aq
+		if (token == "Ao" || token == "Aq") && len(tokens) > i+1 && !p.HasArgument {
+			p.Placeholder = true
+			next := tokens[i+1]
+			if next != "Ar" && next != "Ac" {
+				p.HasArgument = true
+				p.Argument = strings.Trim(next, "<>")
+			}
+		} else if (token == "Ao" || token == "Aq") && len(tokens) > i+1 && !p.HasParameter {
+			// a second placeholder, eg args in Ar bundled-flags Ao Ar args Ac
+			if nested := nest(i); nested != nil {
+				nested.Placeholder = true
+			}
+		}
+
+		// A plain word starting with a plus is a SysV style flag, unless
+		// it's the value of the macro before it
+		if isPlusFlag(rawtoken) && !p.HasFlags && (i == 0 || !takesValue(tokens[i-1])) {
+			p.HasFlags = true
+			p.Flags = []string{rawtoken[1:]}
+			p.Plus = true
+		}
+
+		// .Cm +opt is how SysV style flags are usually written, handled
+		// as a plus flag above rather than as a keyword. .Ql and .Li are
+		// literal text to be typed as it is, just like .Cm.
+		if literalMacros[token] && p.Keyword == "" {
+			if value, j := macroValue(tokens, i); j >= 0 && !isPlusFlag(value) {
+				p.Keyword = value
+			}
+		}
+
+		if token == "Ev" && p.Environment == "" {
+			if value, j := macroValue(tokens, i); j >= 0 {
+				p.Environment = value
+			}
+		}
+
+		if token == "Pa" && p.Path == "" {
+			if value, j := macroValue(tokens, i); j >= 0 {
+				p.Path = value
+			}
+		}
+
+		// .Pf prefix Ar value joins the prefix to the value
+		if token == "Pf" {
+			p.NoSpace = true
+		}
+
+		// .Va names a variable, which is the argument unless it's
+		// assigned a value, as in .Va name Ns = Ns Ar value
+		if token == "Va" && !p.HasArgument {
+			if value, j := macroValue(tokens, i); j >= 0 {
+				p.HasArgument = true
+				p.Variable = value
+				p.Argument = value
+				if k := nextIndex(tokens, j); followedByMacro(tokens, j, "=") && followedByMacro(tokens, k, "Ar") {
+					if v, l := macroValue(tokens, nextIndex(tokens, k)); l >= 0 {
+						p.Argument = value + "=" + v
+					}
+				}
+			}
+		}
+
+		if token == "Ar" && !p.HasArgument {
+			p.HasArgument = true
+			// if the next token is blank, it's a generic non-named argument
+			value, j := macroValue(tokens, i)
+			if j < 0 || value == "..." {
+				p.Argument = "files"
+			} else {
+				p.Argument = value
+			}
+			// a trailing ellipsis means the argument can be repeated,
+			// and .Ar ... is a repeated generic argument
+			if value == "..." || (j >= 0 && followedByEllipsis(tokens, j)) {
+				p.Variadic = true
+			}
+			if p.HasParameter {
+				p.ArgumentLast = true
+				p.NoSpace = joinedToPrevious(tokens, i)
+			}
+		}
+
+		// .Fl on its own is a lone dash, usually standing for standard
+		// input, unless it's joined to what follows as in .Fl Ns Ar opt
+		if token == "Fl" && !p.HasFlags && !p.Stdin {
+			if _, j := macroValues(tokens, i); j < 0 && !joinedToNext(tokens, i) {
+				p.Stdin = true
+				continue
+			}
+		}
+
+		if token == "Fl" && !p.HasFlags {
+			p.HasFlags = true
+			// a single .Fl can give several flags, eg .Fl a b c
+			values, j := macroValues(tokens, i)
+			if j >= 0 {
+				// long options keep their leading dash, which may be
+				// escaped, eg .Fl \-color is stored as -color
+				p.Flags = []string{}
+				for _, value := range values {
+					p.Flags = append(p.Flags, canonicalFlag(value))
+				}
+			} else {
+				p.Flags = []string{"-"}
+			}
+			// synonyms follow a comma, eg .Fl v , Fl \-verbose
+			for j >= 0 && j+2 < len(tokens) && tokens[j+1] == "," && tokens[j+2] == "Fl" {
+				aliases, k := macroValues(tokens, j+2)
+				if k < 0 {
+					break
+				}
+				for _, alias := range aliases {
+					p.Aliases = append(p.Aliases, canonicalFlag(alias))
+				}
+				j = k
+			}
+			// an argument straight after the flags belongs to the last,
+			// and .Ns joins them together, eg .Fl I Ns Ar dir is -Idir.
+			// A long option may be joined to it by an equals sign, as in
+			// .Fl \-color Ns = Ns Ar when. The argument may be a
+			// placeholder, or follow an inline block, as port does in
+			// .Fl D Oo Ar bind_address : Oc Ns Ar port
+			if j >= 0 {
+				k := j
+				equals := followedByMacro(tokens, j, "=")
+				if equals {
+					k = nextIndex(tokens, j)
+				}
+				if n := nextIndex(tokens, k); n >= 0 {
+					if _, ok := inlineBlocks[macroName(tokens[n])]; ok {
+						k = blockEnd(tokens, n)
+					}
+				}
+				// an unclosed block runs to the end of the line
+				if k < len(tokens) && (followedByMacro(tokens, k, "Ao") || followedByMacro(tokens, k, "Aq")) {
+					k = nextIndex(tokens, k)
+				}
+				if k >= 0 && k < len(tokens) && (followedByMacro(tokens, k, "Ar") || followedByMacro(tokens, k, "Va")) {
+					p.ArgumentFlag = p.Flags[len(p.Flags)-1]
+					p.NoSpace = equals || joinedToNext(tokens, j)
+				}
+			}
+			// -v ... or -vvv mean the flag can be given repeatedly
+			if j >= 0 && followedByEllipsis(tokens, j) {
+				p.Countable = true
+			} else if len(p.Flags) == 1 && isRepeatedFlag(p.Flags[0]) {
+				p.Flags = []string{p.Flags[0][:1]}
+				p.Countable = true
+				if p.ArgumentFlag != "" {
+					p.ArgumentFlag = p.Flags[0]
+				}
+			}
+		}
+	}
+	return p, err
+}
+
+// Font changes and other escapes which don't produce any text, eg \fB,
+// \f(CW and the zero width \&
+var markupEscape = regexp.MustCompile(`\\f(\(..|\[[^]]*\]|.)|\\[,/&|^]`)
+
+// The text with any inline markup removed, so flags and arguments don't
+// keep the escapes used to format them, eg \fBfile\fR is file
+func stripMarkup(text string) string {
+	return markupEscape.ReplaceAllString(text, "")
+}
+
+// Special characters which may appear in a name, eg \(hy for a hyphen
+var specialCharacters = map[string]string{
+	"hy": "-", "mi": "-", "en": "-", "aq": "'", "dq": "\"", "ti": "~",
+	"ul": "_", "pl": "+", "sl": "/", "rs": "\\",
+}
+
+// Special characters given as \(xx or \[xx], and the escaped minus and
+// backslash
+var roffEscape = regexp.MustCompile(`\\(\(..|\[[^]]*\]|[-e])`)
+
+// The text with its markup removed and escapes replaced by the
+// characters they stand for, eg git\-commit is git-commit. Special
+// characters without a plain equivalent are dropped.
+func roffText(text string) string {
+	return roffEscape.ReplaceAllStringFunc(stripMarkup(text), func(escape string) string {
+		switch escape {
+		case "\\-":
+			return "-"
+		case "\\e":
+			return "\\"
+		}
+		return specialCharacters[strings.Trim(escape[2:], "[]")]
+	})
+}
+
+// Font and spacing macros which don't contribute anything to the
+// parameter themselves, eg .Fl T Ns Ar term
+var transparentMacros = map[string]bool{"Ns": true, "Em": true, "Sy": true}
+
+// Macros which may appear part way through a line
+var callableMacros = map[string]bool{
+	"Ac": true, "Ao": true, "Aq": true, "Ar": true, "Brc": true, "Bro": true, "Brq": true, "Cm": true,
+	"Ev": true, "Fl": true, "Li": true, "No": true, "Oc": true, "Oo": true,
+	"Op": true, "Pa": true, "Pf": true, "Ql": true, "Sm": true, "Va": true,
+	"Xc": true, "Xo": true,
+}
+
+// Macros giving literal text, such as a subcommand
+var literalMacros = map[string]bool{"Cm": true, "Li": true, "Ql": true}
+
+// Macros grouping what follows them rather than marking it up
+var groupMacros = map[string]bool{"Aq": true, "Brq": true, "Op": true, "Bro": true, "Oo": true}
+
+// The blocks which can open and close on the same line, and the macro
+// grouping the same way up to the end of the line
+var inlineBlocks = map[string]struct{ macro, close string }{
+	"Oo":  {"Op", "Oc"},
+	"Bro": {"Brq", "Brc"},
+}
+
+// Whether the token at tokens[i] is preceded only by groups, as Bro is
+// in Op Bro, so that it groups the rest of the line too. An inline block
+// only does if nothing but punctuation follows its close, so Oo Ar no Oc
+// Ns Ar ecn is an optional prefix to an argument rather than optional as
+// a whole.
+func leadingGroup(tokens []string, i int) bool {
+	for _, token := range tokens[:i] {
+		if !groupMacros[macroName(token)] {
+			return false
+		}
+	}
+	if _, ok := inlineBlocks[macroName(tokens[i])]; ok {
+		for _, token := range tokens[min(blockEnd(tokens, i)+1, len(tokens)):] {
+			if !transparentMacros[token] && !isPunctuation(token) && token != "Oc" && token != "Brc" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// The index just past the group opened at tokens[i]: the end of the line
+// for a macro such as Op, or the matching close of an inline block
+func blockEnd(tokens []string, i int) int {
+	group, ok := inlineBlocks[macroName(tokens[i])]
+	if !ok {
+		return len(tokens)
+	}
+	depth := 0
+	for j := i + 1; j < len(tokens); j++ {
+		switch macroName(tokens[j]) {
+		case macroName(tokens[i]):
+			depth++
+		case group.close:
+			if depth == 0 {
+				return j
+			}
+			depth--
+		}
+	}
+	return len(tokens)
+}
+
+// The value following the macro at tokens[i] and its index, skipping any
+// transparent macros. There's no value, and the index is -1, if the
+// next token is itself a macro.
+func macroValue(tokens []string, i int) (string, int) {
+	for j := i + 1; j < len(tokens); j++ {
+		if transparentMacros[tokens[j]] {
+			continue
+		}
+		if callableMacros[tokens[j]] {
+			return "", -1
+		}
+		return tokens[j], j
+	}
+	return "", -1
+}
+
+// All the values following the macro at tokens[i] up to the next macro
+// or punctuation, and the index of the last of them. The index is -1 if
+// there are no values.
+func macroValues(tokens []string, i int) ([]string, int) {
+	value, j := macroValue(tokens, i)
+	if j < 0 {
+		return []string{}, -1
+	}
+	values := []string{value}
+	for j+1 < len(tokens) {
+		next := tokens[j+1]
+		if callableMacros[next] || transparentMacros[next] || isPunctuation(next) {
+			break
+		}
+		values = append(values, next)
+		j++
+	}
+	return values, j
+}
+
+// Whether the token is only punctuation, such as | or ...
+func isPunctuation(token string) bool {
+	return strings.Trim(token, ".,:;|()[]") == ""
+}
+
+// Whether the macro is followed by a value, such as the name of a flag
+func takesValue(macro string) bool {
+	return macro == "Ar" || macro == "Fl" || macro == ".Ar" || macro == ".Fl"
+}
+
+// Whether the token at tokens[j] is followed by an ellipsis, ignoring
+// any transparent macros in between and the close of a placeholder or
+// block, as in Ao Ar pattern Ac ... or Oo Ar file Oc ...
+func followedByEllipsis(tokens []string, j int) bool {
+	for _, token := range tokens[j+1:] {
+		if !transparentMacros[token] && token != "Ac" && token != "Oc" && token != "Brc" {
+			return token == "..."
+		}
+	}
+	return false
+}
+
+// Whether the token at tokens[j] is followed by the given token, ignoring
+// any transparent macros in between
+func followedByMacro(tokens []string, j int, macro string) bool {
+	for _, token := range tokens[j+1:] {
+		if !transparentMacros[token] {
+			return token == macro
+		}
+	}
+	return false
+}
+
+// The index of the token after tokens[j], ignoring any transparent
+// macros in between, or -1 if there isn't one
+func nextIndex(tokens []string, j int) int {
+	for k := j + 1; k < len(tokens); k++ {
+		if !transparentMacros[tokens[k]] {
+			return k
+		}
+	}
+	return -1
+}
+
+// Whether the token at tokens[i] is joined by .Ns to what's before it,
+// ignoring the .Ao opening a placeholder, as the argument is in
+// Oc Ns Ao Ar commit Ac
+func joinedToPrevious(tokens []string, i int) bool {
+	for k := i - 1; k >= 0; k-- {
+		if tokens[k] != "Ao" && tokens[k] != "Aq" {
+			return tokens[k] == "Ns"
+		}
+	}
+	return false
+}
+
+// Whether the token at tokens[j] is joined to the one after it by .Ns,
+// ignoring any other transparent macros in between
+func joinedToNext(tokens []string, j int) bool {
+	for _, token := range tokens[j+1:] {
+		if !transparentMacros[token] {
+			return false
+		}
+		if token == "Ns" {
+			return true
+		}
+	}
+	return false
+}
+
+func prependDashes(s string) string {
+	lines := strings.Split(s, "\n")
+	out := ""
+	for _, line := range lines {
+		if strings.TrimSpace(line) != "" {
+			out = out + "--" + line + "\n"
+		}
+	}
+	return out
+}
+
+func isValidParameter(p Parameter) bool {
+	return (p.Optional || p.NoSpace || p.HasFlags || p.HasArgument || p.HasParameter || p.Keyword != "" || p.Environment != "" || p.Path != "" || p.Stdin || len(p.Alternatives) > 0)
+}
+
+// Whether any parameter of the command, including nested ones, carries
+// a flag or an argument. Commands without any are just a bare name.
+func hasArguments(c Command) bool {
+	for _, p := range c.allParameters() {
+		if p.HasFlags || p.HasArgument {
+			return true
+		}
+	}
+	return false
+}
+
+// Every parameter of every syntax, with nested parameters flattened
+// out after their parent
+func (c Command) allParameters() []Parameter {
+	params := []Parameter{}
+	for _, syn := range c.Syntaxes {
+		for _, param := range syn.Parameters {
+			params = append(params, param.flatten()...)
+		}
+	}
+	return params
+}
+
+// Every parameter of the syntaxes which aren't a subcommand's, flattened
+// like allParameters
+func (c Command) ownParameters() []Parameter {
+	params := []Parameter{}
+	for _, syn := range c.Syntaxes {
+		if len(syn.Parameters) > 0 && isSubcommandKeyword(syn.Parameters[0]) {
+			continue
+		}
+		for _, param := range syn.Parameters {
+			params = append(params, param.flatten()...)
+		}
+	}
+	return params
+}
+
+// The parameter followed by every parameter nested in it or given as an
+// alternative to it
+func (p Parameter) flatten() []Parameter {
+	params := []Parameter{}
+	for n := &p; n != nil; n = n.Parameter {
+		params = append(params, *n)
+		for _, alt := range n.Alternatives {
+			params = append(params, alt.flatten()...)
+		}
+	}
+	return params
+}
+
+// The full path of every subcommand at any depth, such as "git commit"
+// and "git remote add", in the order they're nested
+func (c Command) SubcommandPaths() []string {
+	paths := []string{}
+	for _, sub := range c.Subcommands {
+		path := c.Name + " " + sub.Name
+		paths = append(paths, path)
+		for _, nested := range sub.SubcommandPaths() {
+			paths = append(paths, c.Name+" "+nested)
+		}
+	}
+	return paths
+}
+
+func isValidSyntax(s Syntax) bool {
+	return (len(s.Parameters) > 0)
+}
+
+// The minimum and maximum number of positional arguments the syntax
+// accepts. The maximum is -1 when a repeatable argument makes it unbounded.
+func (s Syntax) ArgArity() (min int, max int) {
+	for _, p := range s.Parameters {
+		if !p.HasArgument || p.HasFlags {
+			continue
+		}
+		if !p.Optional {
+			min++
+		}
+		if p.Variadic {
+			max = -1
+		} else if max != -1 {
+			max++
+		}
+	}
+	return min, max
+}
+
+func (c Command) String() string {
+	ret := fmt.Sprintf("Command: %s\n", c.Name)
+	if c.Section != "" {
+		ret = ret + fmt.Sprintf("Section: %s\n", c.Section)
+	}
+	if c.Description != "" {
+		ret = ret + fmt.Sprintf("Description: %s\n", c.Description)
+	}
+	for _, syn := range c.Syntaxes {
+		ret = ret + prependDashes(syn.String()) + "\n"
+	}
+	return ret
+}
+
+func (s Syntax) String() string {
+	ret := ""
+	for _, param := range s.Parameters {
+		ret = ret + param.String() + "\n"
+	}
+	return ret
+}
+
+func (p Parameter) String() string {
+	ret := ""
+	if p.Optional {
+		ret = ret + "--optional\n"
+	}
+	if p.Choice {
+		ret = ret + "--choice\n"
+	}
+	if p.NoSpace {
+		ret = ret + "--nospace\n"
+	}
+	if p.HasFlags {
+		ret = ret + fmt.Sprintf("--flags: %s\n", strings.Join(p.Flags, " "))
+	}
+	if len(p.Aliases) > 0 {
+		ret = ret + fmt.Sprintf("--aliases: %s\n", strings.Join(p.Aliases, " "))
+	}
+	if p.Plus {
+		ret = ret + "--plus\n"
+	}
+	if p.Stdin {
+		ret = ret + "--stdin\n"
+	}
+	if p.Countable {
+		ret = ret + "--countable\n"
+	}
+	if p.Keyword != "" {
+		ret = ret + "--command: " + p.Keyword + "\n"
+	}
+	if p.Environment != "" {
+		ret = ret + "--environment: " + p.Environment + "\n"
+	}
+	if p.Path != "" {
+		ret = ret + "--path: " + p.Path + "\n"
+	}
+	if p.Variable != "" {
+		ret = ret + "--variable: " + p.Variable + "\n"
+	}
+	if p.HasArgument {
+		ret = ret + "--has argument: " + p.Argument
+		if p.Placeholder {
+			ret = ret + " (placeholder)"
+		}
+		if p.Variadic {
+			ret = ret + " (repeated)"
+		}
+		if p.ArgumentLast {
+			ret = ret + " (after nested parameter)"
+		}
+		ret = ret + "\n"
+	}
+	if p.HasParameter {
+		ret = ret + "--has nested parameter:\n" + prependDashes(p.Parameter.String())
+	}
+	if len(p.Alternatives) > 0 {
+		ret = ret + "--one of:\n"
+		for _, alt := range p.Alternatives {
+			ret = ret + prependDashes(alt.String())
+		}
+	}
+	if ret != "" {
+		ret = "Parameter:\n" + ret
+	}
+	return ret
+}
@@ -0,0 +1,993 @@
+package kgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/iotest"
+)
+
+func TestOptionalVariadic(t *testing.T) {
+	for _, synopsis := range []string{
+		".Op Ar file ...",
+		".Oo\n.Ar file ...\n.Oc",
+		".Oo Ar file ... Oc",
+	} {
+		c, err := ParseManText(mdocPage(synopsis))
+		if err != nil {
+			t.Errorf("%q: %v", synopsis, err)
+			continue
+		}
+		p := c.Syntaxes[0].Parameters[0]
+		if !p.Optional || !p.Variadic || p.Argument != "file" {
+			t.Errorf("%q: got %+v, want optional variadic file", synopsis, p)
+		}
+		if want := []string{"foo [file ...]"}; !reflect.DeepEqual(c.Usage(), want) {
+			t.Errorf("%q: usage %q, want %q", synopsis, c.Usage(), want)
+		}
+	}
+}
+
+func TestBraceAndAngleGroups(t *testing.T) {
+	tests := []struct {
+		synopsis string
+		want     string
+	}{
+		{".Bro Fl a | Fl b Brc", "foo {-a | -b}"},
+		{".Bro\n.Fl a | Fl b\n.Brc", "foo {-a | -b}"},
+		{".Brq Fl a | Fl b", "foo {-a | -b}"},
+		{".Ao Ar address Ac", "foo <address>"},
+		{".Ao address Ac", "foo <address>"},
+		{".Aq Ar host", "foo <host>"},
+		{".Fl L Ao Ar port Ac", "foo -L <port>"},
+	}
+	for _, test := range tests {
+		c, err := ParseManText(mdocPage(test.synopsis))
+		if err != nil {
+			t.Errorf("%q: %v", test.synopsis, err)
+			continue
+		}
+		if got := c.Usage(); !reflect.DeepEqual(got, []string{test.want}) {
+			t.Errorf("%q: usage %q, want %q", test.synopsis, got, test.want)
+		}
+	}
+
+	c, err := ParseManText(mdocPage(".Bro Fl a | Fl b Brc"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := c.Syntaxes[0].Parameters[0]; p.Optional || len(p.Alternatives) != 2 {
+		t.Errorf("brace group is %+v, want a required choice of two", p)
+	}
+	c, err = ParseManText(mdocPage(".Ao Ar address Ac"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := c.Syntaxes[0].Parameters[0]; !p.Placeholder || p.Argument != "address" {
+		t.Errorf("angle group is %+v, want the placeholder address", p)
+	}
+}
+
+func TestMissingPathErrors(t *testing.T) {
+	missing := "testdata/no-such-dir"
+	if _, err := getFileList(missing); err == nil {
+		t.Error("getFileList: no error for a missing directory")
+	}
+	if _, _, err := ParseManFiles(missing, 0, 0, ParseOptions{}); err == nil {
+		t.Error("ParseManFiles: no error for a missing directory")
+	}
+	if _, err := ParseFile(missing + "/foo.1"); err == nil {
+		t.Error("ParseFile: no error for a missing file")
+	}
+}
+
+// A synopsis for foo and the usages it should parse to
+type usageTest struct {
+	synopsis string
+	want     []string
+}
+
+func checkUsages(t *testing.T, tests []usageTest) {
+	t.Helper()
+	for _, test := range tests {
+		c, err := ParseManText(mdocPage(test.synopsis))
+		if err != nil {
+			t.Errorf("%q: %v", test.synopsis, err)
+			continue
+		}
+		if got := c.Usage(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: usage %q, want %q", test.synopsis, got, test.want)
+		}
+	}
+}
+
+func TestTabSeparatedMacros(t *testing.T) {
+	checkUsages(t, []usageTest{
+		{".Op Fl a\t\n.Op\tFl b\tAr file", []string{"foo [-a] [-b file]"}},
+		{".Fl\t\tc \t Ar\tdir", []string{"foo -c dir"}},
+	})
+	if got := tokenize(".Op\tFl\tv"); !reflect.DeepEqual(got, []string{".Op", "Fl", "v"}) {
+		t.Errorf("tokenize split tabs into %q", got)
+	}
+}
+
+func TestNestingDepthGuard(t *testing.T) {
+	var buf bytes.Buffer
+	parser := Parser{Options: ParseOptions{Logger: slog.New(slog.NewTextHandler(&buf, nil))}}
+	for _, deep := range []string{
+		".Op " + strings.Repeat("Oo Fl a ", 200) + strings.Repeat("Oc ", 200),
+		".Op " + strings.Repeat("Op Fl a ", 200),
+	} {
+		// the line nested too deeply is cut off at the limit, and the
+		// lines around it are parsed as usual
+		c, err := parser.ParseReader(strings.NewReader(mdocPage(".Op Fl v\n" + deep + "\n.Ar file")))
+		if err != nil {
+			t.Fatalf("%.20s...: %v", deep, err)
+		}
+		ps := c.Syntaxes[0].Parameters
+		if len(ps) != 3 || !reflect.DeepEqual(ps[0].Flags, []string{"v"}) || ps[2].Argument != "file" {
+			t.Errorf("%.20s...: got %+v, want -v, the deep line and file", deep, ps)
+		} else if d := nestingDepth(ps[1]); d != maxParameterDepth {
+			t.Errorf("%.20s...: nested %d levels, want %d", deep, d, maxParameterDepth)
+		}
+		if err := c.Validate(); err != nil {
+			t.Error(err)
+		}
+	}
+	if want := `level=WARN msg="parameter nested too deeply, ignoring the rest" limit=32`; !strings.Contains(buf.String(), want) {
+		t.Errorf("missing %q:\n%s", want, buf.String())
+	}
+
+	parser = Parser{Options: ParseOptions{MaxDepth: 2}}
+	c, err := parser.ParseReader(strings.NewReader(mdocPage(".Op Fl a Op Fl b Op Fl c Op Fl d")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d := nestingDepth(c.Syntaxes[0].Parameters[0]); d != 2 {
+		t.Errorf("nested %d levels, want the limit of 2", d)
+	}
+}
+
+// The number of parameters nested one inside the next under p
+func nestingDepth(p Parameter) int {
+	depth := 0
+	for p.Parameter != nil {
+		p = *p.Parameter
+		depth++
+	}
+	return depth
+}
+
+func TestArgArity(t *testing.T) {
+	tests := []struct {
+		synopsis string
+		min, max int
+	}{
+		{".Ar source\n.Ar target", 2, 2},
+		{".Ar file\n.Op Ar mode", 1, 2},
+		{".Ar file\n.Op Ar more ...", 1, -1},
+		{".Op Fl x", 0, 0},
+	}
+	for _, test := range tests {
+		c, err := ParseManText(mdocPage(test.synopsis))
+		if err != nil {
+			t.Errorf("%q: %v", test.synopsis, err)
+			continue
+		}
+		if min, max := c.Syntaxes[0].ArgArity(); min != test.min || max != test.max {
+			t.Errorf("%q: arity %d, %d, want %d, %d", test.synopsis, min, max, test.min, test.max)
+		}
+	}
+}
+
+func TestLiteralSynopsis(t *testing.T) {
+	page := ".Dd January 1, 2024\n.Dt FOO 1\n.Os\n.Sh SYNOPSIS\n.Bd -literal\nfoo [-a] [-o file] input\nfoo -h\n.Ed\n.Sh DESCRIPTION\nDoes things.\n"
+	c, err := ParseManText(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"foo [-a] [-o file] <input>", "foo -h"}; !reflect.DeepEqual(c.Usage(), want) {
+		t.Errorf("usage %q, want %q", c.Usage(), want)
+	}
+	if !c.LowConfidence {
+		t.Error("a literal synopsis isn't marked low confidence")
+	}
+}
+
+func TestCountableFlags(t *testing.T) {
+	for _, synopsis := range []string{".Op Fl v ...", ".Op Fl vvv"} {
+		c, err := ParseManText(mdocPage(synopsis))
+		if err != nil {
+			t.Errorf("%q: %v", synopsis, err)
+			continue
+		}
+		p := c.Syntaxes[0].Parameters[0]
+		if !p.Countable || !reflect.DeepEqual(p.Flags, []string{"v"}) || p.Variadic {
+			t.Errorf("%q: got %+v, want the countable flag v", synopsis, p)
+		}
+		if want := []string{"foo [-v ...]"}; !reflect.DeepEqual(c.Usage(), want) {
+			t.Errorf("%q: usage %q, want %q", synopsis, c.Usage(), want)
+		}
+	}
+}
+
+func TestAnglePlaceholders(t *testing.T) {
+	checkUsages(t, []usageTest{
+		{".Ao\n.Ar address\n.Ac", []string{"foo <address>"}},
+		{".Op Fl b Ao Ar address Ac", []string{"foo [-b <address>]"}},
+	})
+	c, err := ParseManText(mdocPage(".Op Fl b Ao Ar address Ac"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := c.Syntaxes[0].Parameters[0]; p.Argument != "address" || p.ArgumentFlag != "b" || !p.Placeholder {
+		t.Errorf("got %+v, want -b taking the placeholder address", p)
+	}
+}
+
+func TestParseManFilesLimit(t *testing.T) {
+	tests := []struct {
+		lower, upper, limit int
+		want                []string
+	}{
+		{0, 0, 0, []string{"ls", "git-commit", "gzip", "ls", "rlogin", "tar"}},
+		{0, 0, 2, []string{"ls", "git-commit"}},
+		{2, 0, 2, []string{"gzip", "ls"}},
+		{4, 6, 5, []string{"rlogin", "tar"}},
+	}
+	for _, test := range tests {
+		commands, _, err := ParseManFiles("testdata/man1", test.lower, test.upper, ParseOptions{Limit: test.limit})
+		if err != nil {
+			t.Fatal(err)
+		}
+		names := []string{}
+		for _, c := range commands {
+			names = append(names, c.Name)
+		}
+		if !reflect.DeepEqual(names, test.want) {
+			t.Errorf("range %d:%d limit %d: got %q, want %q", test.lower, test.upper, test.limit, names, test.want)
+		}
+	}
+}
+
+func TestArgumentOnNextLine(t *testing.T) {
+	checkUsages(t, []usageTest{
+		{".Fl f\n.Ar file", []string{"foo -f file"}},
+		{".Fl f\n.Ar file ...", []string{"foo -f file ..."}},
+		// an optional flag is complete, and a new usage isn't a continuation
+		{".Op Fl f\n.Ar file", []string{"foo [-f] <file>"}},
+		{".Fl f\n.Nm foo\n.Ar file", []string{"foo -f", "foo <file>"}},
+		{".Fl f\n.Fl g", []string{"foo -f -g"}},
+	})
+	c, err := ParseManText(mdocPage(".Fl f\n.Ar file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := c.Syntaxes[0].Parameters; len(p) != 1 || p[0].ArgumentFlag != "f" {
+		t.Errorf("got %+v, want -f taking file", p)
+	}
+}
+
+func TestParseManText(t *testing.T) {
+	tests := []struct {
+		text    string
+		name    string
+		section string
+		usage   []string
+	}{
+		{mdocPage(".Op Fl a"), "foo", "1", []string{"foo [-a]"}},
+		{manPage(`\fBfoo\fR [\-a]`), "foo", "1", []string{"foo [-a]"}},
+	}
+	for _, test := range tests {
+		c, err := ParseManText(test.text)
+		if err != nil {
+			t.Errorf("%q: %v", test.text, err)
+			continue
+		}
+		if c.Name != test.name || c.Section != test.section || !reflect.DeepEqual(c.Usage(), test.usage) {
+			t.Errorf("%q: got %s(%s) %q, want %s(%s) %q", test.text, c.Name, c.Section, c.Usage(), test.name, test.section, test.usage)
+		}
+	}
+	if _, err := ParseManText("just some text\n"); !errors.Is(err, ErrNoSynopsis) {
+		t.Errorf("plain text: got %v, want %v", err, ErrNoSynopsis)
+	}
+}
+
+func TestParseReader(t *testing.T) {
+	f, err := os.Open("testdata/man1/ls.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	got, err := ParseReader(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want, err := ParseFile("testdata/man1/ls.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(want) {
+		t.Errorf("reader and file differ: %s", Diff(got, want))
+	}
+}
+
+func TestJSON(t *testing.T) {
+	c, err := ParseManText(mdocPage(".Op Fl w Ar local_tun Ns Op : Ns Ar remote_tun"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := json.Marshal(c.Syntaxes[0].Parameters[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"optional":true,"nospace":false,"hasargument":true,"argument":"local_tun","hasflags":true,"flags":["w"],"argumentflag":"w","hasparameter":true,` +
+		`"parameter":{"optional":true,"nospace":true,"hasargument":true,"argument":"remote_tun","hasflags":false,"hasparameter":false}}`
+	if string(data) != want {
+		t.Errorf("got\n%s\nwant\n%s", data, want)
+	}
+
+	data, err = json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Command
+	if err := json.Unmarshal(data, &back); err != nil {
+		t.Fatal(err)
+	}
+	if d := Diff(back, c); d != "" {
+		t.Errorf("round trip: %s", d)
+	}
+}
+
+func TestGetManFiles(t *testing.T) {
+	root := t.TempDir()
+	for _, name := range []string{"man1/ls.1", "man8/sshd.8.gz", "fr/man1/ls.1", "whatis"} {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	files, err := getManFiles(root)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]string{}
+	for _, f := range files {
+		rel, _ := filepath.Rel(root, f.Path)
+		got[rel] = f.Section
+	}
+	want := map[string]string{"man1/ls.1": "1", "man8/sshd.8.gz": "8", "fr/man1/ls.1": "1", "whatis": ""}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+
+	commands, _, err := ParseManFiles("testdata", 0, 0, ParseOptions{Recursive: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commands) != 6 {
+		t.Errorf("got %d commands under testdata, want one per fixture", len(commands))
+	}
+}
+
+func TestRedirects(t *testing.T) {
+	dir, err := ParseFile("testdata/man1/dir.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ls, err := ParseFile("testdata/man1/ls.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !dir.Equal(ls) {
+		t.Errorf("dir.1 isn't ls.1: %s", Diff(dir, ls))
+	}
+
+	root := t.TempDir()
+	loop := writePage(t, root, "a.1", ".so man1/b.1\n")
+	writePage(t, root, "b.1", "\n.\\\" a stub\n.so man1/a.1\n")
+	if _, err := ParseFile(loop); !errors.Is(err, ErrRedirectLoop) {
+		t.Errorf("loop: got %v, want %v", err, ErrRedirectLoop)
+	}
+	missing := writePage(t, root, "c.1", ".so man1/nothing.1\n")
+	if _, err := ParseFile(missing); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("missing target: got %v, want %v", err, fs.ErrNotExist)
+	}
+	gzipped := writePage(t, root, "d.1", ".so man1/gzip.1\n")
+	data, err := os.ReadFile("testdata/man1/gzip.1.gz")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "man1", "gzip.1.gz"), data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if c, err := ParseFile(gzipped); err != nil || c.Name != "gzip" {
+		t.Errorf("compressed target: got %q, %v, want gzip", c.Name, err)
+	}
+}
+
+func TestGetDefinedName(t *testing.T) {
+	for _, name := range []string{"git-rebase", "mysqld_safe", "a2ensite", "7z", "g++", "systemd.unit", "\"my tool\""} {
+		lines := []string{".Sh NAME", ".Nm " + name + " ,", ".Nd does things"}
+		if got, want := getDefinedName(lines), strings.Trim(name, "\""); got != want {
+			t.Errorf("%s: got %q, want %q", name, got, want)
+		}
+	}
+	if got := getDefinedName([]string{".Nm Fl v"}); got != "" {
+		t.Errorf("macro taken as a name: %q", got)
+	}
+}
+
+func TestVariadicArguments(t *testing.T) {
+	checkUsages(t, []usageTest{
+		{".Ar file ...", []string{"foo <file> ..."}},
+		{".Op Ar file ...", []string{"foo [file ...]"}},
+		{".Op Fl I Ar dir ...", []string{"foo [-I dir ...]"}},
+		{".Ar", []string{"foo <files>"}},
+	})
+	c, err := ParseManText(mdocPage(".Ar source ...\n.Ar target"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if min, max := c.Syntaxes[0].ArgArity(); min != 2 || max != -1 {
+		t.Errorf("arity %d %d, want 2 -1", min, max)
+	}
+}
+
+func TestFlagWithArgument(t *testing.T) {
+	checkUsages(t, []usageTest{
+		{".Fl f Ar file", []string{"foo -f file"}},
+		{".Op Fl o Ar output", []string{"foo [-o output]"}},
+	})
+	c, err := ParseManText(mdocPage(".Op Fl o Ar output"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := c.Syntaxes[0].Parameters[0]
+	if !reflect.DeepEqual(p.Flags, []string{"o"}) || p.Argument != "output" || p.ArgumentFlag != "o" || !p.Optional {
+		t.Errorf("got %+v, want optional -o taking output", p)
+	}
+	if got, want := c.OptString(), "o:"; got != want {
+		t.Errorf("optstring %q, want %q", got, want)
+	}
+}
+
+func TestCommentLines(t *testing.T) {
+	checkUsages(t, []usageTest{
+		{".Op Fl a\n.\\\" .Op Fl x\n.Op Fl b", []string{"foo [-a] [-b]"}},
+		{".Op Fl a\n'\\\" .Op Fl y\n.\\# .Op Fl z\n.\\\"\n.Op Fl b", []string{"foo [-a] [-b]"}},
+	})
+	for line, want := range map[string]bool{
+		".\\\" a comment":         true,
+		"'\\\" t":                 true,
+		".\\# a groff comment":    true,
+		".Op Fl a \\\" a comment": false,
+		"\\\"quoted\\\"":          false,
+	} {
+		if got := isCommentLine(line); got != want {
+			t.Errorf("%q: got %v, want %v", line, got, want)
+		}
+	}
+}
+
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		line string
+		want []string
+	}{
+		{".Op Fl f Ar file", []string{".Op", "Fl", "f", "Ar", "file"}},
+		{`.Ar "input file"`, []string{".Ar", "input file"}},
+		{`.Ar "say ""hi"""`, []string{".Ar", `say "hi"`}},
+		{`.Ar input\ file`, []string{".Ar", `input\ file`}},
+		{`.Ar ""`, []string{".Ar", ""}},
+		{`.Ar a"b`, []string{".Ar", `a"b`}},
+	}
+	for _, test := range tests {
+		if got := tokenize(test.line); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.line, got, test.want)
+		}
+	}
+	checkUsages(t, []usageTest{
+		{`.Op Fl o Ar "output file"`, []string{"foo [-o output file]"}},
+	})
+}
+
+func TestMultiLineOptionalBlocks(t *testing.T) {
+	checkUsages(t, []usageTest{
+		{".Oo\n.Fl p Ar port\n.Oc", []string{"foo [-p port]"}},
+		{".Oo\n.Fl a\n.Oc\n.Oo\n.Fl b\n.Oc", []string{"foo [-a] [-b]"}},
+		{".Oo Fl x\n.Ar file\n.Oc", []string{"foo [-x file]"}},
+		{".Oo\n.Fl p Ar port\n.Oc\n.Ar host", []string{"foo [-p port] <host>"}},
+	})
+}
+
+func TestContinuationBlocks(t *testing.T) {
+	checkUsages(t, []usageTest{
+		{".Xo\n.Fl o\n.Ar opt\n.Xc", []string{"foo -o opt"}},
+		{".Op Fl o Xo\n.Ar opt\n.Xc", []string{"foo [-o opt]"}},
+		{".Op Fl a\n.Xo\n.Fl o\n.Ar opt\n.Xc\n.Op Fl b", []string{"foo [-a] -o opt [-b]"}},
+	})
+}
+
+func TestAlternatives(t *testing.T) {
+	checkUsages(t, []usageTest{
+		{".Op Fl a | Fl b", []string{"foo [-a | -b]"}},
+		{".Fl a | Fl b", []string{"foo (-a | -b)"}},
+		{".Op Fl a | Fl b | Fl c", []string{"foo [-a | -b | -c]"}},
+		{".Op Ar file | Fl \\-stdin", []string{"foo [<file> | --stdin]"}},
+	})
+	c, err := ParseManText(mdocPage(".Op Fl a | Fl b Ar file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	p := c.Syntaxes[0].Parameters[0]
+	if !p.Optional || len(p.Alternatives) != 2 {
+		t.Fatalf("got %+v, want two optional alternatives", p)
+	}
+	if a, b := p.Alternatives[0], p.Alternatives[1]; a.HasArgument || b.Argument != "file" || b.ArgumentFlag != "b" {
+		t.Errorf("got %+v and %+v, want -a and -b file", a, b)
+	}
+}
+
+func TestNestingTruncated(t *testing.T) {
+	deep := strings.Repeat(" Op Fl x", maxParameterDepth+1)
+	for _, line := range []string{".Op Fl a" + deep, ".Fl a" + deep, ".Ar file" + deep} {
+		p, err := buildParameter(tokenize(line), ParseOptions{})
+		if err != nil {
+			t.Errorf("%.20s...: %v", line, err)
+		} else if d := nestingDepth(p); d != maxParameterDepth {
+			t.Errorf("%.20s...: nested %d levels, want %d", line, d, maxParameterDepth)
+		}
+	}
+	// nesting within the limit is kept whole
+	p, err := buildParameter(tokenize(".Op Fl a Op Fl b Op Fl c"), ParseOptions{})
+	if err != nil || nestingDepth(p) != 2 {
+		t.Errorf("got %+v, %v, want -a with -b and -c nested in it", p, err)
+	}
+}
+
+func TestMalformedNestingTerminates(t *testing.T) {
+	for _, line := range []string{
+		".Op Op Op Op",
+		".Op Oo Oo Fl a",
+		".Oc Oc Op",
+		".Op " + strings.Repeat("Op ", 10*maxParameterDepth),
+		".Op " + strings.Repeat("Oo Fl a ", 2*maxParameterDepth),
+	} {
+		if _, err := buildParameter(tokenize(line), ParseOptions{}); err != nil {
+			t.Errorf("%.20s...: %v", line, err)
+		}
+	}
+}
+
+// A page with CRLF line endings parses the same as with LF ones. The
+// page has no .Dt, so the name has to come from .Nm.
+func TestCRLF(t *testing.T) {
+	lf := strings.Replace(mdocPage(".Op Fl a\n.Op Fl b Ar file"), ".Dt FOO 1\n", "", 1)
+	lf = strings.Replace(lf, ".Nm foo", ".Nm frob", 1)
+	want, err := ParseManText(lf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	crlf := strings.ReplaceAll(lf, "\n", "\r\n")
+	c, err := ParseManText(crlf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !c.Equal(want) {
+		t.Errorf("CRLF page differs: %s", Diff(want, c))
+	}
+	if c.Name != "frob" {
+		t.Errorf("name %q, want frob", c.Name)
+	}
+	if want := []string{"frob [-a] [-b file]"}; !reflect.DeepEqual(c.Usage(), want) {
+		t.Errorf("usage %q, want %q", c.Usage(), want)
+	}
+	r, err := ParseReader(strings.NewReader(crlf))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !r.Equal(c) {
+		t.Errorf("ParseReader differs: %s", Diff(c, r))
+	}
+}
+
+func TestParseReaderMatchesFile(t *testing.T) {
+	page := mdocPage(".Op Fl v\n.Op Fl o Ar file\n.Ar input ...")
+	path := writePage(t, t.TempDir(), "foo.1", page)
+	want, err := ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Equal(want) {
+		t.Errorf("reader and file differ: %s", Diff(got, want))
+	}
+	if _, err := ParseReader(iotest.ErrReader(io.ErrUnexpectedEOF)); !errors.Is(err, io.ErrUnexpectedEOF) {
+		t.Errorf("got %v, want the reader's error", err)
+	}
+}
+
+func TestStripMarkup(t *testing.T) {
+	for text, want := range map[string]string{
+		`\fBv\fR`:        "v",
+		`\f(CWlong\fP`:   "long",
+		`\f[CB]name\f[]`: "name",
+		`\&.profile`:     ".profile",
+		`a\|b\^c`:        "abc",
+		`\-\-color`:      `\-\-color`,
+	} {
+		if got := stripMarkup(text); got != want {
+			t.Errorf("%s: got %q, want %q", text, got, want)
+		}
+	}
+	checkUsages(t, []usageTest{
+		{`.Op Fl \fBv\fR`, []string{"foo [-v]"}},
+		{`.Op Fl o Ar \fIfile\fR`, []string{"foo [-o file]"}},
+		{`.Fl \-\fBcolor\fP`, []string{"foo --color"}},
+		{`.Op Fl \&a`, []string{"foo [-a]"}},
+	})
+}
+
+func TestNoSpace(t *testing.T) {
+	checkUsages(t, []usageTest{
+		{".Fl I Ns Ar dir", []string{"foo -Idir"}},
+		{".Op Fl I Ns Ar dir", []string{"foo [-Idir]"}},
+		{".Op Fl \\-color Ns = Ns Ar when", []string{"foo [--color=when]"}},
+		{".Fl o Ar file", []string{"foo -o file"}},
+	})
+	for synopsis, want := range map[string]bool{
+		".Fl I Ns Ar dir":    true,
+		".Fl a Pf + Ar line": true,
+		".Fl o Ar file":      false,
+	} {
+		c, err := ParseManText(mdocPage(synopsis))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.Syntaxes[0].Parameters[0].NoSpace; got != want {
+			t.Errorf("%q: nospace %v, want %v", synopsis, got, want)
+		}
+	}
+}
+
+func TestSynopsisHeaders(t *testing.T) {
+	for line, want := range map[string]bool{
+		".Sh SYNOPSIS":     true,
+		".Sh \"SYNOPSIS\"": true,
+		".Sh SYNOPSIS  ":   true,
+		".Sh\tSYNOPSIS":    true,
+		".SH \"SYNOPSIS\"": true,
+		".SH SYNOPSIS":     true,
+		".Sh DESCRIPTION":  false,
+		"SYNOPSIS":         false,
+	} {
+		if got := isSynopsisLine(line); got != want {
+			t.Errorf("%q: got %v, want %v", line, got, want)
+		}
+	}
+	page := strings.Replace(mdocPage(".Op Fl a"), ".Sh SYNOPSIS\n", ".Sh \"SYNOPSIS\" \n", 1)
+	c, err := ParseManText(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"foo [-a]"}; !reflect.DeepEqual(c.Usage(), want) {
+		t.Errorf("usage %q, want %q", c.Usage(), want)
+	}
+}
+
+func TestSyntaxPerNameLine(t *testing.T) {
+	checkUsages(t, []usageTest{
+		{".Op Fl a\n.Nm foo Fl b\n.Nm Op Fl c\n.Nm\n.Ar file", []string{"foo [-a]", "foo -b", "foo [-c]", "foo <file>"}},
+		{".Fl a\n.Nm foo\n.Fl b", []string{"foo -a", "foo -b"}},
+	})
+	for line, want := range map[string]bool{
+		".Nm":          true,
+		".Nm foo":      true,
+		".Nm foo Fl v": true,
+		".Nm Op Fl v":  true,
+		".Nm\tfoo":     true,
+		".Nmx":         false,
+		".Op Nm foo":   false,
+	} {
+		if got := isNameLine(line); got != want {
+			t.Errorf("%q: got %v, want %v", line, got, want)
+		}
+	}
+}
+
+func TestConditionals(t *testing.T) {
+	checkUsages(t, []usageTest{
+		{".if n .Op Fl a\n.Op Fl d", []string{"foo [-a] [-d]"}},
+		{".ie n .Op Fl a\n.el .Op Fl x\n.Op Fl d", []string{"foo [-a] [-d]"}},
+		{".ie t \\{\\\n.Op Fl b\n.\\}\n.el \\{\\\n.Op Fl c\n.Op Fl e\n.\\}\n.Op Fl d", []string{"foo [-b] [-d]"}},
+	})
+	got := stripConditionals([]string{".if \\n(.g \\{\\", ".ds x y", ".\\}", ".Op Fl a"})
+	if want := []string{".ds x y", ".Op Fl a"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestEnvironmentAndPaths(t *testing.T) {
+	checkUsages(t, []usageTest{
+		{".Ev PATH", []string{"foo $PATH"}},
+		{".Op Ev FOO", []string{"foo [$FOO]"}},
+		{".Pa /etc/foo.conf", []string{"foo /etc/foo.conf"}},
+		{".Op Fl f Pa file", []string{"foo [-f file]"}},
+	})
+	c, err := ParseManText(mdocPage(".Ev PATH\n.Pa /etc/foo.conf"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ps := c.Syntaxes[0].Parameters; ps[0].Environment != "PATH" || ps[1].Path != "/etc/foo.conf" {
+		t.Errorf("got %+v, want the variable PATH and the path /etc/foo.conf", ps)
+	}
+}
+
+func TestParseManFilesReturnsCommands(t *testing.T) {
+	commands, _, err := ParseManFiles("testdata/man1", 0, 0, ParseOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, c := range commands {
+		names = append(names, c.Name)
+	}
+	want := []string{"ls", "git-commit", "gzip", "ls", "rlogin", "tar"}
+	if !reflect.DeepEqual(names, want) {
+		t.Errorf("got %q, want %q", names, want)
+	}
+}
+
+func TestFormattingRequests(t *testing.T) {
+	synopsis := ".Op Fl a\n.Pp\n.Op Fl b\n.br\n.sp\n.Op Fl c\n.Bk -words\n.Op Fl d\n.Ek"
+	checkUsages(t, []usageTest{
+		{synopsis, []string{"foo [-a] [-b] [-c] [-d]"}},
+	})
+	c, err := Parser{Options: ParseOptions{Strict: true}}.ParseReader(strings.NewReader(mdocPage(synopsis)))
+	if err != nil {
+		t.Fatalf("strict: %v", err)
+	}
+	if c.UnknownMacros != 0 {
+		t.Errorf("%d unknown macros, want none", c.UnknownMacros)
+	}
+}
+
+func TestLogger(t *testing.T) {
+	root := t.TempDir()
+	writePage(t, root, "a.1", mdocPage(".Op Fl a"))
+	writePage(t, root, "b.1.gz", "not gzipped")
+	writePage(t, root, "README", "Not a man page.\n")
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	if _, _, err := ParseManFiles(filepath.Join(root, "man1"), 0, 0, ParseOptions{Logger: logger}); err != nil {
+		t.Fatal(err)
+	}
+	out := buf.String()
+	for _, want := range []string{
+		"level=WARN msg=\"skipping page\" path=" + filepath.Join(root, "man1", "b.1.gz"),
+		"level=DEBUG msg=\"skipping file without a synopsis\" path=" + filepath.Join(root, "man1", "README"),
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "a.1") {
+		t.Errorf("logged a page which parsed:\n%s", out)
+	}
+}
+
+func TestNonManFiles(t *testing.T) {
+	root := t.TempDir()
+	readme := writePage(t, root, "README", "Not a man page.\nJust some text.\n")
+	if _, err := ParseFile(readme); !errors.Is(err, ErrNoSynopsis) {
+		t.Errorf("plain text: got %v, want %v", err, ErrNoSynopsis)
+	}
+	corrupt := writePage(t, root, "index.gz", "not gzipped")
+	for _, path := range []string{corrupt, filepath.Join(root, "missing.1")} {
+		_, err := ParseFile(path)
+		if err == nil || errors.Is(err, ErrNoSynopsis) {
+			t.Errorf("%s: got %v, want a read error", filepath.Base(path), err)
+		}
+	}
+}
+
+func TestSection(t *testing.T) {
+	root := t.TempDir()
+	// the header's section wins over the directory's
+	page := strings.Replace(mdocPage(".Op Fl a"), ".Dt FOO 1", ".Dt FOO 8", 1)
+	path := writePage(t, root, "foo.8", page)
+	c, err := ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Section != "8" || !strings.Contains(c.String(), "Section: 8\n") {
+		t.Errorf("section %q, want 8 in\n%s", c.Section, c)
+	}
+	// without one in the header, the directory gives it
+	path = writePage(t, root, "bar.1", strings.Replace(page, ".Dt FOO 8\n", "", 1))
+	if c, err = ParseFile(path); err != nil || c.Section != "1" {
+		t.Errorf("section %q, %v, want 1 from man1", c.Section, err)
+	}
+	data, err := json.Marshal(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"section":"1"`) {
+		t.Errorf("no section in %s", data)
+	}
+	for path, want := range map[string]string{
+		"/usr/share/man/man1/ls.1":      "1",
+		"/usr/share/man/man3p/foo.3p":   "3p",
+		"/usr/share/man/fr/man8/x.8.gz": "8",
+		"/tmp/ls.1":                     "",
+	} {
+		if got := sectionOf(path); got != want {
+			t.Errorf("%s: got %q, want %q", path, got, want)
+		}
+	}
+}
+
+func TestBareDash(t *testing.T) {
+	checkUsages(t, []usageTest{
+		{".Op Fl", []string{"foo [-]"}},
+		{".Fl", []string{"foo -"}},
+		{".Op Ar file | Fl", []string{"foo [<file> | -]"}},
+	})
+	c, err := ParseManText(mdocPage(".Op Fl\n.Op Fl v"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := c.Syntaxes[0].Parameters[0]; !p.Stdin || p.HasFlags {
+		t.Errorf("got %+v, want stdin rather than a flag", p)
+	}
+	if got, want := c.OptString(), "v"; got != want {
+		t.Errorf("optstring %q, want %q", got, want)
+	}
+}
+
+func TestNoMacro(t *testing.T) {
+	checkUsages(t, []usageTest{
+		{".Fl x No text Ar file", []string{"foo -x file"}},
+		{".Op Fl x No text Ar file", []string{"foo [-x file]"}},
+		{".Op Fl w No \\&", []string{"foo [-w]"}},
+	})
+	c, err := ParseManText(mdocPage(".Fl x No text Ar file"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := c.Syntaxes[0].Parameters[0]; p.Argument != "file" || !reflect.DeepEqual(p.Flags, []string{"x"}) {
+		t.Errorf("got %+v, want -x taking file", p)
+	}
+	if got, want := dropEmptyNo([]string{".Fl", "x", "No", "Ar", "file", "No"}), []string{".Fl", "x", "Ar", "file"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestByteOrderMark(t *testing.T) {
+	// the mark would hide the .Dt starting the first line
+	page := "\ufeff" + strings.Replace(mdocPage(".Op Fl a"), ".Dd January 1, 2024\n", "", 1)
+	c, err := ParseManText(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"foo [-a]"}; !reflect.DeepEqual(c.Usage(), want) || c.Section != "1" {
+		t.Errorf("usage %q section %q, want %q in section 1", c.Usage(), c.Section, want)
+	}
+	path := writePage(t, t.TempDir(), "foo.1", page)
+	f, err := ParseFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !f.Equal(c) {
+		t.Errorf("file differs: %s", Diff(f, c))
+	}
+}
+
+func TestVariables(t *testing.T) {
+	checkUsages(t, []usageTest{
+		{".Va name", []string{"foo <name>"}},
+		{".Op Va name Ns = Ns Ar value", []string{"foo [name=value]"}},
+		{".Op Fl D Va name", []string{"foo [-D name]"}},
+	})
+	c, err := ParseManText(mdocPage(".Op Va name Ns = Ns Ar value"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p := c.Syntaxes[0].Parameters[0]; p.Variable != "name" || p.Argument != "name=value" {
+		t.Errorf("got %+v, want the variable name assigned a value", p)
+	}
+}
+
+func TestSynopsisWithoutLeadingName(t *testing.T) {
+	tests := []struct {
+		name string
+		page string
+		want []string
+	}{
+		{"rlogin", ".Dd January 1, 2024\n.Dt RLOGIN 1\n.Os\n.Sh NAME\n.Nm rlogin\n.Nd remote login\n.Sh SYNOPSIS\n.Op Fl 8EKLd\n.Op Fl l Ar username\n.Ar host\n.Sh DESCRIPTION\n",
+			[]string{"rlogin [-8EKLd] [-l username] <host>"}},
+		{"bar", ".Dd January 1, 2024\n.Dt BAR 1\n.Os\n.Sh SYNOPSIS\n.Op Fl a\n.Nm bar\n.Fl b\n.Sh DESCRIPTION\n",
+			[]string{"bar [-a]", "bar -b"}},
+	}
+	for _, test := range tests {
+		c, err := ParseManText(test.page)
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if c.Name != test.name || !reflect.DeepEqual(c.Usage(), test.want) {
+			t.Errorf("got %s %q, want %s %q", c.Name, c.Usage(), test.name, test.want)
+		}
+	}
+}
+
+func TestDropEmpty(t *testing.T) {
+	root := t.TempDir()
+	writePage(t, root, "a.1", mdocPage(".Op Fl a"))
+	writePage(t, root, "b.1", mdocPage(".Cm start"))
+	writePage(t, root, "c.1", mdocPage(""))
+	dir := filepath.Join(root, "man1")
+	for _, test := range []struct {
+		opts ParseOptions
+		want int
+	}{
+		{ParseOptions{}, 2},
+		{ParseOptions{DropEmpty: true}, 1},
+	} {
+		commands, _, err := ParseManFiles(dir, 0, 0, test.opts)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(commands) != test.want {
+			t.Errorf("drop empty %v: got %d commands, want %d", test.opts.DropEmpty, len(commands), test.want)
+		}
+	}
+}
+
+func TestFontResetsAreTransparent(t *testing.T) {
+	checkUsages(t, []usageTest{
+		{".Fl T Ns Ar term", []string{"foo -Tterm"}},
+		{".Op Fl W Ns No Ar warning", []string{"foo [-Wwarning]"}},
+		{".Op Fl T No Ns Ar term", []string{"foo [-Tterm]"}},
+		{".Op Fl x No Ar file", []string{"foo [-x file]"}},
+	})
+	c, err := ParseManText(mdocPage(".Op Fl W Ns No Ar warning"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ps := c.Syntaxes[0].Parameters; len(ps) != 1 || ps[0].ArgumentFlag != "W" || !ps[0].NoSpace {
+		t.Errorf("got %+v, want -W joined to warning", ps)
+	}
+}
+
+func TestReadErrorText(t *testing.T) {
+	missing := "testdata/no-such-dir"
+	_, err := getFileList(missing)
+	if want := "reading directory " + missing + ": "; err == nil || !strings.HasPrefix(err.Error(), want) {
+		t.Errorf("getFileList: got %v, want it to start %q", err, want)
+	}
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("getFileList: %v doesn't wrap %v", err, fs.ErrNotExist)
+	}
+	corrupt := writePage(t, t.TempDir(), "foo.1.gz", "not gzipped")
+	_, err = ParseFile(corrupt)
+	if want := "reading " + corrupt + ": "; err == nil || !strings.HasPrefix(err.Error(), want) || strings.Contains(err.Error(), "%") {
+		t.Errorf("ParseFile: got %v, want it to start %q", err, want)
+	}
+}
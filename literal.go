@@ -0,0 +1,207 @@
+package kgo
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Some pages give the whole synopsis as preformatted text in a
+// .Bd -literal display rather than using macros. Collect the usages in
+// such a block, rewritten as the equivalent mdoc lines so they can be
+// built like any other synopsis. This is best effort: tokens with a
+// leading dash or plus are taken to be flags, everything else arguments.
+func getLiteralSynopsisLines(lines []string) [][]string {
+	synopsis := [][]string{}
+	inLiteral := false
+
+	for _, line := range getSynopsisSection(lines) {
+		if strings.HasPrefix(line, ".Bd") && strings.Contains(line, "-literal") {
+			inLiteral = true
+			continue
+		}
+		if strings.HasPrefix(line, ".Ed") {
+			inLiteral = false
+			continue
+		}
+		if !inLiteral || strings.TrimSpace(line) == "" || strings.HasPrefix(line, ".") {
+			continue
+		}
+		// Indented lines continue the usage above them
+		continued := strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")
+		tokens := tokenize(line)
+		if !continued || len(synopsis) == 0 {
+			synopsis = append(synopsis, []string{".Nm " + tokens[0]})
+			tokens = tokens[1:]
+		}
+		usage := len(synopsis) - 1
+		synopsis[usage] = append(synopsis[usage], literalToMacros(tokens)...)
+	}
+	return synopsis
+}
+
+// The close of each bracket a usage can group with, and the inline block
+// it's written as
+var literalGroups = map[string]struct{ close, open, macroClose string }{
+	"[": {"]", "Oo", "Oc"},
+	"(": {")", "Bro", "Brc"},
+	"{": {"}", "Bro", "Brc"},
+}
+
+// Rewrite preformatted usage tokens such as [-o file] as mdoc lines such
+// as .Oo Fl o Ar file Oc, one line per top level token or bracketed
+// group. Parentheses and braces grouping alternatives, as in
+// (-c | -C), become Bro and Brc blocks, and a placeholder such as
+// <commit> is Ao Ar commit Ac. A piece joined to the one before it, as
+// <mode> is in -u<mode>, is joined to it with Ns. A closing bracket
+// without an opening one, as pages sometimes have, is dropped.
+func literalToMacros(tokens []string) []string {
+	macroLines := []string{}
+	line := []string{}
+	// the closing bracket of each open group, innermost last
+	open := []string{}
+	// whether the piece before can have the next one joined to it, which
+	// it can't if it opens a group or separates alternatives
+	joinable := false
+
+	for _, token := range tokens {
+		for n, piece := range splitLiteral(token) {
+			// a new top level token starts a new line, unless it's an
+			// ellipsis repeating the group before it or continues a
+			// list of alternatives
+			alternative := len(line) > 0 && line[len(line)-1] == "|"
+			if n == 0 && len(open) == 0 && len(line) > 0 && piece != "..." && piece != "|" && !alternative {
+				macroLines = append(macroLines, "."+strings.Join(line, " "))
+				line = []string{}
+			}
+			joined := n > 0 && joinable
+			joinable = true
+			macros := []string{}
+			switch {
+			case literalGroups[piece].close != "":
+				open = append(open, literalGroups[piece].close)
+				macros = append(macros, literalGroups[piece].open)
+				joinable = false
+			case piece == "]" || piece == ")" || piece == "}":
+				if !slices.Contains(open, piece) {
+					continue
+				}
+				// closing any groups left open inside it
+				for {
+					inner := open[len(open)-1]
+					open = open[:len(open)-1]
+					for _, group := range literalGroups {
+						if group.close == inner {
+							line = append(line, group.macroClose)
+							break
+						}
+					}
+					if inner == piece {
+						break
+					}
+				}
+				continue
+			case piece == "|":
+				line = append(line, piece)
+				joinable = false
+				continue
+			case piece == "...":
+				line = append(line, piece)
+				continue
+			case piece == "-":
+				// a lone dash, usually meaning standard input
+				macros = append(macros, "Fl")
+			case isPlusFlag(piece):
+				macros = append(macros, piece)
+			case strings.HasPrefix(piece, "-"):
+				// a long option may give its argument after an equals
+				// sign, eg --color=when
+				name, value, equals := piece, "", false
+				if strings.HasPrefix(piece, "--") {
+					name, value, equals = strings.Cut(piece, "=")
+				}
+				macros = append(macros, "Fl", name[1:])
+				if equals {
+					macros = append(macros, "Ns", "=")
+				}
+				if value != "" {
+					macros = append(macros, "Ns", "Ar", value)
+				}
+			case strings.HasPrefix(piece, "<") && strings.HasSuffix(piece, ">") && len(piece) > 2:
+				macros = append(macros, "Ao", "Ar", piece[1:len(piece)-1], "Ac")
+			case isPunctuation(piece) || piece == "=":
+				macros = append(macros, piece)
+			default:
+				macros = append(macros, "Ar", piece)
+			}
+			if joined {
+				line = append(line, "Ns")
+			}
+			line = append(line, macros...)
+		}
+	}
+	if len(line) > 0 {
+		macroLines = append(macroLines, "."+strings.Join(line, " "))
+	}
+	return macroLines
+}
+
+// Split a usage token into brackets, bars, ellipses, placeholders and
+// the words between them, eg -u<mode> is -u and <mode>, and
+// [(amend|reword):] is [, (, amend, |, reword, ), : and ]. A placeholder
+// part way through a word other than a flag, as in /<regex>/, is left
+// in the word, and double quotes are dropped.
+func splitLiteral(token string) []string {
+	pieces := []string{}
+	word := ""
+	// parentheses opened part way through the word
+	parens := 0
+	flush := func() {
+		if word != "" {
+			pieces = append(pieces, word)
+			word = ""
+		}
+	}
+	for i := 0; i < len(token); i++ {
+		switch {
+		case token[i] == '\\' && i+1 < len(token):
+			// an escape, such as the \*( of a string, is part of the word
+			word = word + token[i:i+2]
+			i++
+		case strings.HasPrefix(token[i:], "..."):
+			flush()
+			pieces = append(pieces, "...")
+			i += 2
+		case token[i] == '"':
+			// shell quoting in an example, which would otherwise quote
+			// the macros after it
+		case token[i] == '<' && strings.Contains(token[i:], ">") && (word == "" || joinsPlaceholder(word)):
+			flush()
+			end := i + strings.Index(token[i:], ">")
+			pieces = append(pieces, token[i:end+1])
+			i = end
+		case token[i] == '(' && word != "":
+			// part of the word, as in OPTION(S) or git-instaweb(1)
+			word = word + "("
+			parens++
+		case token[i] == ')' && parens > 0:
+			word = word + ")"
+			parens--
+		case strings.ContainsRune("[](){}|", rune(token[i])):
+			flush()
+			pieces = append(pieces, token[i:i+1])
+		default:
+			word = word + token[i:i+1]
+		}
+	}
+	flush()
+	return pieces
+}
+
+// Whether a placeholder straight after the word is the argument of a
+// flag, as in -u<mode> or --file=<name>, rather than part of the word,
+// as in --suffix=.<sfx>
+func joinsPlaceholder(word string) bool {
+	last := word[len(word)-1]
+	return strings.HasPrefix(word, "-") && (last == '=' || unicode.IsLetter(rune(last)) || unicode.IsDigit(rune(last)))
+}
@@ -0,0 +1,105 @@
+package kgo
+
+import (
+	"strings"
+)
+
+// Whether the page is written with the traditional man(7) macros rather
+// than mdoc, which is the case for many GNU tools. Such pages start with
+// .TH where mdoc pages use .Dt.
+func isManFormat(lines []string) bool {
+	th := false
+	for _, line := range lines {
+		if strings.HasPrefix(line, ".Dt") {
+			return false
+		}
+		if strings.HasPrefix(line, ".TH") {
+			th = true
+		}
+	}
+	return th
+}
+
+// Font macros which alternate between two fonts for each argument,
+// running the arguments together, eg .RI [ OPTION ] is [OPTION]
+var alternatingFontMacros = map[string]bool{
+	".BI": true, ".BR": true, ".IB": true, ".IR": true, ".RB": true, ".RI": true,
+}
+
+// Font macros which set all their arguments in one font
+var fontMacros = map[string]bool{".B": true, ".I": true, ".R": true, ".SM": true, ".SB": true}
+
+// Requests and macros which break the line, separating one usage from the
+// next
+var manBreakMacros = map[string]bool{
+	".br": true, ".sp": true, ".PP": true, ".P": true, ".LP": true, ".SS": true,
+	".TP": true, ".IP": true, ".HP": true, ".nf": true, ".fi": true, ".": true,
+}
+
+// The text a line of man(7) source produces, without fonts or escapes
+func manText(line string) string {
+	tokens := tokenize(line)
+	if len(tokens) > 0 && alternatingFontMacros[tokens[0]] {
+		line = strings.Join(tokens[1:], "")
+	} else if len(tokens) > 0 && fontMacros[tokens[0]] {
+		line = strings.Join(tokens[1:], " ")
+	}
+	line = stripMarkup(line)
+	line = strings.TrimSuffix(line, "\\")
+	line = strings.ReplaceAll(line, "\\ ", " ")
+	line = strings.ReplaceAll(line, "\\-", "-")
+	line = strings.ReplaceAll(line, "\\e", "\\")
+	return line
+}
+
+// Collect the usages in the synopsis of a man(7) page, rewritten as the
+// equivalent mdoc lines in the same way as a literal synopsis. The
+// macros only set the fonts, eg
+//
+//	.B grep
+//	.RI [ OPTION .\|.\|.]
+//	.I PATTERNS
+//
+// so the text of each usage is put back together and then split into
+// flags and arguments. A new usage starts after a break such as .br.
+// Pages for subcommands often give the command's name as words, eg
+// git commit for git-commit, which are all part of the name.
+func getManSynopsisLines(lines []string, name string) [][]string {
+	synopsis := [][]string{}
+	usage := []string{}
+	flush := func() {
+		tokens := strings.Fields(strings.Join(usage, " "))
+		usage = []string{}
+		if len(tokens) == 0 {
+			return
+		}
+		words := 1
+		for k := 2; k <= len(tokens); k++ {
+			if strings.Join(tokens[:k], "-") == name {
+				words = k
+			}
+		}
+		syn := []string{".Nm " + tokens[0]}
+		synopsis = append(synopsis, append(syn, literalToMacros(tokens[words:])...))
+	}
+
+	for _, line := range getSynopsisSection(lines) {
+		macro := ""
+		if tokens := tokenize(line); len(tokens) > 0 {
+			macro = tokens[0]
+		}
+		if manBreakMacros[macro] {
+			flush()
+			continue
+		}
+		if strings.HasPrefix(macro, ".") && !alternatingFontMacros[macro] && !fontMacros[macro] {
+			continue
+		}
+		// Separate any ellipsis so it follows the argument or group it
+		// repeats, eg [FILE]... or [FILE...]
+		text := strings.ReplaceAll(manText(line), "...", " ... ")
+		usage = append(usage, text)
+	}
+	flush()
+	return synopsis
+}
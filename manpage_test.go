@@ -0,0 +1,50 @@
+package kgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestManSynopsisGroups(t *testing.T) {
+	tests := []struct {
+		synopsis string
+		want     []string
+	}{
+		{`\fBfoo\fR [\-u<mode>] [\-\-amend]`, []string{"foo [-u<mode>] [--amend]"}},
+		{
+			`\fBfoo\fR [(\-c | \-C | \-\-squash) <commit> | \-\-fixup [(amend|reword):]<commit>)]`,
+			[]string{"foo [{-c | -C | --squash} <commit> | --fixup [{<amend> | <reword>}]<commit>]"},
+		},
+		{`\fBfoo\fR [\-\-] [<pathspec>\&...]`, []string{"foo [--] [<pathspec> ...]"}},
+		{`\fBfoo\fR [\-D [bind_address:]port] (\-a|\-b)`, []string{"foo [-D [bind_address:]port] {-a | -b}"}},
+		// a group left open doesn't swallow the usage after the break
+		{".B foo\n[\\-a\n.br\n.B foo\n.I FILE", []string{"foo [-a]", "foo <FILE>"}},
+	}
+	for _, test := range tests {
+		c, err := ParseManText(manPage(test.synopsis))
+		if err != nil {
+			t.Errorf("%q: %v", test.synopsis, err)
+			continue
+		}
+		if got := c.Usage(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: usage %q, want %q", test.synopsis, got, test.want)
+		}
+	}
+}
+
+func TestManSynopsisPlaceholder(t *testing.T) {
+	c, err := ParseManText(manPage(`\fBfoo\fR [\-u<mode>] <commit>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	params := c.Syntaxes[0].Parameters
+	if len(params) != 2 {
+		t.Fatalf("got %d parameters, want 2", len(params))
+	}
+	if flag := params[0]; !reflect.DeepEqual(flag.Flags, []string{"u"}) || flag.Argument != "mode" || !flag.NoSpace {
+		t.Errorf("-u<mode> is %+v, want flag u joined to argument mode", flag)
+	}
+	if arg := params[1]; arg.Argument != "commit" || !arg.Placeholder || arg.HasFlags {
+		t.Errorf("<commit> is %+v, want a placeholder argument", arg)
+	}
+}
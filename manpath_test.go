@@ -0,0 +1,25 @@
+package kgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestManPaths(t *testing.T) {
+	tests := []struct {
+		manpath string
+		want    []string
+	}{
+		{"", []string{DefaultManPath}},
+		{"/opt/man", []string{"/opt/man"}},
+		{"/opt/man:", []string{"/opt/man", DefaultManPath}},
+		{"/usr/share/man::", []string{DefaultManPath}},
+		{"/opt/man/:/usr/share/man:/opt/man", []string{"/opt/man", DefaultManPath}},
+	}
+	for _, test := range tests {
+		t.Setenv("MANPATH", test.manpath)
+		if got := ManPaths(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("MANPATH=%q: got %q, want %q", test.manpath, got, test.want)
+		}
+	}
+}
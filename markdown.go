@@ -0,0 +1,63 @@
+package kgo
+
+import (
+	"strings"
+)
+
+var markdownEscaper = strings.NewReplacer(
+	"\\", "\\\\", "`", "\\`", "*", "\\*", "_", "\\_", "[", "\\[",
+	"]", "\\]", "<", "\\<", ">", "\\>", "#", "\\#", "|", "\\|",
+)
+
+// Escape the characters in prose that Markdown would treat as markup
+func escapeMarkdown(s string) string {
+	return markdownEscaper.Replace(s)
+}
+
+// Document the command in Markdown: a heading with its name, its
+// description, a usage block and a list of its flags with what the page
+// says about each
+func (c Command) Markdown() string {
+	ret := "# " + escapeMarkdown(c.Name) + "\n\n"
+	if c.Description != "" {
+		ret = ret + escapeMarkdown(c.Description) + "\n\n"
+	}
+
+	ret = ret + "## Usage\n\n```\n"
+	for _, syn := range c.Syntaxes {
+		ret = ret + syn.usage(c.Name) + "\n"
+	}
+	ret = ret + "```\n"
+
+	flags := []string{}
+	seen := map[string]bool{}
+	for _, p := range c.allParameters() {
+		for _, flag := range p.flagList() {
+			name := p.displayFlag(flag)
+			if seen[name] {
+				continue
+			}
+			seen[name] = true
+			item := "- `" + name
+			if p.takesArgument(flag) {
+				// joined as it is in the usage, eg -Idir or --color=when
+				sep := " "
+				if _, long := longOptionName(flag); p.NoSpace && long {
+					sep = "="
+				} else if p.NoSpace {
+					sep = ""
+				}
+				item = item + sep + p.Argument
+			}
+			item = item + "`"
+			if summary := c.Options[name].summary(); summary != "" {
+				item = item + ": " + escapeMarkdown(summary)
+			}
+			flags = append(flags, item)
+		}
+	}
+	if len(flags) > 0 {
+		ret = ret + "\n## Flags\n\n" + strings.Join(flags, "\n") + "\n"
+	}
+	return ret
+}
@@ -0,0 +1,25 @@
+package kgo
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestMarkdownGolden(t *testing.T) {
+	c, err := ParseFile("testdata/man1/ls.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "testdata/golden/ls.md", []byte(c.Markdown()))
+}
+
+func TestMarkdownEscapes(t *testing.T) {
+	page := mdocPage(".Op Fl a") + ".Bl -tag\n.It Fl a\nShow *all* files_with [brackets].\n.El\n"
+	c, err := ParseManText(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out := c.Markdown(); !strings.Contains(out, `Show \*all\* files\_with \[brackets\].`) {
+		t.Errorf("description not escaped in\n%s", out)
+	}
+}
@@ -0,0 +1,140 @@
+package kgo
+
+import (
+	"strings"
+)
+
+// The command's syntaxes written back out as an mdoc SYNOPSIS section,
+// one .Nm line per syntax followed by a line per parameter, eg
+//
+//	.Sh SYNOPSIS
+//	.Nm ssh
+//	.Op Fl 46
+//	.Op Fl i Ar identity_file
+//	.Ar destination
+//
+// which is useful for stub pages and for checking the parser, since
+// parsing the section again gives the same syntaxes for most commands.
+func (c Command) ToMdoc() string {
+	ret := ".Sh SYNOPSIS\n"
+	for _, syn := range c.Syntaxes {
+		ret = ret + ".Nm " + quoteToken(c.Name) + "\n"
+		for _, p := range syn.Parameters {
+			if line := p.mdoc(); line != "" {
+				ret = ret + "." + line + "\n"
+			}
+		}
+	}
+	return ret
+}
+
+// The macros and values giving the parameter, without the leading dot,
+// eg Op Fl o Ar file
+func (p Parameter) mdoc() string {
+	parts := []string{}
+	if p.Optional {
+		parts = append(parts, "Op")
+	}
+	if p.Choice {
+		parts = append(parts, "Brq")
+	}
+	if len(p.Alternatives) > 0 {
+		alts := []string{}
+		for _, alt := range p.Alternatives {
+			if m := alt.mdoc(); m != "" {
+				alts = append(alts, m)
+			}
+		}
+		return strings.Join(append(parts, strings.Join(alts, " | ")), " ")
+	}
+
+	switch {
+	case p.HasFlags && p.Plus:
+		parts = append(parts, "Cm", "+"+strings.Join(p.Flags, " "))
+	case p.Stdin:
+		parts = append(parts, "Fl")
+	case p.HasFlags:
+		parts = append(parts, "Fl")
+		for _, flag := range p.Flags {
+			parts = append(parts, quoteToken(flag))
+		}
+		for _, alias := range p.Aliases {
+			parts = append(parts, ",", "Fl", quoteToken(alias))
+		}
+		if p.Countable {
+			parts = append(parts, "...")
+		}
+	}
+	if p.Keyword != "" {
+		parts = append(parts, "Cm", quoteToken(p.Keyword))
+	}
+	if p.Environment != "" {
+		parts = append(parts, "Ev", quoteToken(p.Environment))
+	}
+	if p.Path != "" {
+		parts = append(parts, "Pa", quoteToken(p.Path))
+	}
+	arg := []string{}
+	if p.HasArgument {
+		// a flag joined to its argument, eg .Fl I Ns Ar dir, or with an
+		// equals sign for a long option
+		if p.NoSpace && p.ArgumentFlag != "" && !p.ArgumentLast {
+			if _, long := longOptionName(p.ArgumentFlag); long {
+				arg = append(arg, "Ns", "=")
+			}
+			arg = append(arg, "Ns")
+		}
+		if p.Placeholder {
+			arg = append(arg, "Aq")
+		}
+		if p.Variable == "" {
+			arg = append(arg, "Ar", quoteToken(p.Argument))
+		} else if value := strings.TrimPrefix(p.Argument, p.Variable+"="); value != p.Argument {
+			arg = append(arg, "Va", quoteToken(p.Variable), "Ns", "=", "Ns", "Ar", quoteToken(value))
+		} else {
+			arg = append(arg, "Va", quoteToken(p.Variable))
+		}
+		if p.Variadic {
+			arg = append(arg, "...")
+		}
+	}
+	if !p.ArgumentLast {
+		parts = append(parts, arg...)
+	}
+	if p.HasParameter && p.Parameter != nil {
+		if nested := p.Parameter.mdoc(); nested != "" {
+			if p.Parameter.NoSpace {
+				parts = append(parts, "Ns")
+			}
+			parts = append(parts, inlineBlock(nested))
+		}
+	}
+	// an argument after the nested group, which may be joined to it, as
+	// in .Fl D Oo Ar bind_address Oc Ns Ar port
+	if p.ArgumentLast {
+		if p.NoSpace {
+			parts = append(parts, "Ns")
+		}
+		parts = append(parts, arg...)
+	}
+	return strings.Join(parts, " ")
+}
+
+// A nested group written as an inline block, eg Oo Ar file Oc for
+// Op Ar file, so it ends where the group does rather than with the line
+func inlineBlock(m string) string {
+	for open, group := range inlineBlocks {
+		if rest, ok := strings.CutPrefix(m, group.macro+" "); ok {
+			return open + " " + rest + " " + group.close
+		}
+	}
+	return m
+}
+
+// Quote a value if it would otherwise be split into several tokens
+func quoteToken(s string) string {
+	if s == "" || strings.ContainsAny(s, " \t\"") {
+		return quoteString(strings.ReplaceAll(s, "\"", "\"\""))
+	}
+	return s
+}
@@ -0,0 +1,60 @@
+package kgo
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+// Parse the command's mdoc back, failing if it doesn't give the same
+// syntaxes
+func checkRoundTrip(t *testing.T, c Command) {
+	t.Helper()
+	back, err := ParseManText(c.ToMdoc())
+	if err != nil {
+		t.Errorf("%s: %v in\n%s", c.Name, err, c.ToMdoc())
+		return
+	}
+	got := Command{Name: back.Name, Syntaxes: back.Syntaxes}
+	want := Command{Name: c.Name, Syntaxes: c.Syntaxes}
+	if d := Diff(got, want); d != "" {
+		t.Errorf("%s: %s in\n%s", c.Name, d, c.ToMdoc())
+	}
+}
+
+func TestToMdocRoundTripFixtures(t *testing.T) {
+	fixtures, err := filepath.Glob("testdata/man1/*")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, fixture := range fixtures {
+		c, err := ParseFile(fixture)
+		if err != nil {
+			t.Fatal(err)
+		}
+		checkRoundTrip(t, c)
+	}
+}
+
+func TestToMdocRoundTrip(t *testing.T) {
+	for _, c := range []Command{
+		newCmd("ls").syntax().
+			flag("a").optional().
+			arg("file").optional().variadic().
+			build(),
+		newCmd("ssh-agent").syntax().
+			flag("c").optional().or().withFlag("s").
+			syntax().
+			flag("k").
+			build(),
+		newCmd("ssh").syntax().
+			flag("w").optional().withArg("local_tun").
+			nest().optional().nospace().withArg("remote_tun").
+			build(),
+		newCmd("git").syntax().
+			keyword("commit").
+			flag("m").optional().withArg("msg").
+			build(),
+	} {
+		checkRoundTrip(t, c)
+	}
+}
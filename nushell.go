@@ -0,0 +1,137 @@
+package kgo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// The nushell type accepting an argument of the given type
+func nushellType(t ArgType) string {
+	switch t {
+	case ArgFile:
+		return "path"
+	case ArgDirectory:
+		return "directory"
+	case ArgInteger:
+		return "int"
+	}
+	return "string"
+}
+
+var nonIdentifier = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// An extern definition giving nushell the command's signature, eg
+//
+//	export extern "ssh" [
+//		-p: int # Port to connect to on the remote host.
+//		destination: string
+//		...rest: string
+//	]
+//
+// Flags and positional arguments from every syntax are merged. Nushell
+// only allows single letter short flags, so single dash flags with
+// longer names and plus flags are left out. A long option with a single
+// letter synonym is given with it as an alias, eg --all(-a). Positional
+// arguments not common to every syntax are optional, and only the first
+// repeatable one is kept, as the rest parameter.
+func (c Command) NushellCompletion() string {
+	ret := ""
+	if c.Description != "" {
+		ret = ret + "# " + c.Description + "\n"
+	}
+	ret = ret + fmt.Sprintf("export extern %q [\n", c.Name)
+
+	// The long option each short flag is an alias of
+	aliases := map[string]string{}
+	for _, p := range c.allParameters() {
+		for _, flag := range p.flagList() {
+			long, ok := longOptionName(flag)
+			if short := c.shortFlag(p, flag); ok && long != "" && short != "" && aliases[short] == "" {
+				aliases[short] = long
+			}
+		}
+	}
+
+	seen := map[string]bool{}
+	for _, p := range c.allParameters() {
+		if p.Plus {
+			continue
+		}
+		for _, flag := range p.flagList() {
+			entry := ""
+			short := ""
+			if long, ok := longOptionName(flag); ok {
+				entry = "--" + long
+				if s := c.shortFlag(p, flag); s != "" && aliases[s] == long {
+					short = s
+				}
+			} else if len(flag) == 1 && aliases[flag] == "" {
+				entry = "-" + flag
+			}
+			if entry == "" || seen[entry] {
+				continue
+			}
+			seen[entry] = true
+			summary := c.Options[p.displayFlag(flag)].summary()
+			if short != "" {
+				entry = entry + "(-" + short + ")"
+				if summary == "" {
+					summary = c.Options["-"+short].summary()
+				}
+			}
+			if p.takesArgument(flag) {
+				entry = entry + ": " + nushellType(p.argType())
+			}
+			if summary != "" {
+				entry = entry + " # " + summary
+			}
+			ret = ret + "\t" + entry + "\n"
+		}
+	}
+
+	// How many syntaxes each positional argument appears in
+	common := map[string]int{}
+	for _, syn := range c.Syntaxes {
+		names := map[string]bool{}
+		for _, p := range syn.Parameters {
+			if p.HasArgument && !p.HasFlags && !names[p.Argument] {
+				names[p.Argument] = true
+				common[p.Argument]++
+			}
+		}
+	}
+
+	required := []string{}
+	optional := []string{}
+	rest := ""
+	for _, syn := range c.Syntaxes {
+		for _, p := range syn.Parameters {
+			if !p.HasArgument || p.HasFlags {
+				continue
+			}
+			name := strings.Trim(nonIdentifier.ReplaceAllString(p.Argument, "_"), "_")
+			if name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+			typ := nushellType(p.argType())
+			if p.Variadic {
+				if rest == "" {
+					rest = "..." + name + ": " + typ
+				}
+			} else if p.Optional || common[p.Argument] < len(c.Syntaxes) {
+				optional = append(optional, name+"?: "+typ)
+			} else {
+				required = append(required, name+": "+typ)
+			}
+		}
+	}
+	for _, positional := range append(required, optional...) {
+		ret = ret + "\t" + positional + "\n"
+	}
+	if rest != "" {
+		ret = ret + "\t" + rest + "\n"
+	}
+	return ret + "]\n"
+}
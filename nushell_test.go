@@ -0,0 +1,55 @@
+package kgo
+
+import (
+	"testing"
+)
+
+func TestNushellCompletion(t *testing.T) {
+	page := mdocPage(".Op Fl v\n.Op Fl p Ar port\n.Op Fl \\-color Ns = Ns Ar when\n.Ar file ...") + ".Bl -tag\n.It Fl v\nBe verbose.\n.El\n"
+	c, err := ParseManText(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "export extern \"foo\" [\n" +
+		"\t-v # Be verbose.\n" +
+		"\t-p: int\n" +
+		"\t--color: string\n" +
+		"\t...file: path\n" +
+		"]\n"
+	if got := c.NushellCompletion(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestNushellAliases(t *testing.T) {
+	page := mdocPage(".Op Fl a , Fl \\-all\n.Op Fl l\n.Op Fl \\-long\n.Op Fl \\-color") +
+		".Bl -tag\n.It Fl l , Fl \\-long\nUse a long listing.\n.El\n"
+	c, err := ParseManText(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "export extern \"foo\" [\n" +
+		"\t--all(-a)\n" +
+		"\t--long(-l) # Use a long listing.\n" +
+		"\t--color\n" +
+		"]\n"
+	if got := c.NushellCompletion(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
+
+// Only the arguments some syntax leaves out are optional
+func TestNushellOptionalPositionals(t *testing.T) {
+	c, err := ParseManText(mdocPage(".Ar host\n.Ar command\n.Nm foo\n.Op Fl l\n.Ar host"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "export extern \"foo\" [\n" +
+		"\t-l\n" +
+		"\thost: string\n" +
+		"\tcommand?: string\n" +
+		"]\n"
+	if got := c.NushellCompletion(); got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}
@@ -0,0 +1,267 @@
+package kgo
+
+import (
+	"strings"
+)
+
+// What the page says about one of the command's flags
+type Option struct {
+	Description string `json:"description"`
+	// Other flags the description says the option only works with
+	Requires []string `json:"requires,omitempty"`
+	Default  string   `json:"default,omitempty"`
+	// Other flags the same entry describes, eg --all for -a given by
+	// .It Fl a , Fl \-all
+	Synonyms []string `json:"synonyms,omitempty"`
+}
+
+// The first sentence of the description, short enough to show next to a
+// completion
+func (o Option) summary() string {
+	if i := strings.Index(o.Description, ". "); i >= 0 {
+		return o.Description[:i+1]
+	}
+	return o.Description
+}
+
+// Sections which describe the flags in a .Bl list with an .It entry for
+// each one
+var optionSections = []string{"DESCRIPTION", "OPTIONS"}
+
+// Whether the .Sh line starts one of the optionSections
+func isOptionSection(tokens []string) bool {
+	for _, name := range optionSections {
+		if strings.EqualFold(strings.Join(tokens[1:], " "), name) {
+			return true
+		}
+	}
+	return false
+}
+
+// The options described by .It Fl entries in the DESCRIPTION or OPTIONS
+// section, keyed by flag as it's typed, eg -v. The description is the
+// first paragraph of the entry, so for
+//
+//	.It Fl p Ar port
+//	Port to connect to on the remote host.
+//
+// -p is described as "Port to connect to on the remote host." An entry
+// listing several flags describes each of them. The name is used for any
+// bare .Nm in the descriptions.
+func getOptions(lines []string, name string) map[string]Option {
+	options := map[string]Option{}
+	inSection := false
+	flags := []string{}
+	text := []string{}
+
+	finish := func() {
+		description := strings.Join(text, " ")
+		if description != "" {
+			for _, flag := range flags {
+				synonyms := []string{}
+				for _, other := range flags {
+					if other != flag {
+						synonyms = append(synonyms, other)
+					}
+				}
+				if len(synonyms) == 0 {
+					synonyms = nil
+				}
+				options[flag] = Option{
+					Description: description,
+					Requires:    parseRequires(description),
+					Default:     parseDefault(description),
+					Synonyms:    synonyms,
+				}
+			}
+		}
+		flags = []string{}
+		text = []string{}
+	}
+
+	for _, line := range lines {
+		tokens := tokenize(line)
+		macro := ""
+		if len(tokens) > 0 {
+			macro = tokens[0]
+		}
+		switch {
+		case macro == ".Sh" || macro == ".SH":
+			finish()
+			inSection = isOptionSection(tokens)
+		case !inSection:
+		case macro == ".It":
+			finish()
+			flags = itemFlags(tokens)
+		case macro == ".Pp" || macro == ".Bl" || macro == ".El" || macro == ".Ss":
+			finish()
+		case len(flags) > 0:
+			if t := mdocText(tokens, name); t != "" {
+				text = append(text, t)
+			}
+		}
+	}
+	finish()
+	return options
+}
+
+// The flags an .It line introduces, eg -B for .It Fl B Ar bind_interface.
+// Items which don't start with a flag, such as a command taking flags of
+// its own, don't introduce any.
+func itemFlags(tokens []string) []string {
+	flags := []string{}
+	if len(tokens) < 2 || tokens[1] != "Fl" {
+		return flags
+	}
+	for i, token := range tokens {
+		if token != "Fl" {
+			continue
+		}
+		values, j := macroValues(tokens, i)
+		if j < 0 {
+			continue
+		}
+		for _, value := range values {
+			flags = append(flags, flagName(stripMarkup(value)))
+		}
+	}
+	return flags
+}
+
+// The mdoc macros which can be called from within a line, and so may
+// appear part way through a line of prose
+var inlineMacros = macroSet(`Ac Ad An Ao Ap Aq Ar At Bc Bo Bq Brc Bro Brq
+	Bsx Bx Cd Cm Dc Do Dq Dv Dx Ec Em En Eo Er Ev Fa Fc Fl Fn Fo Fr Ft Fx
+	Ic Li Lk Ms Mt Nm No Ns Nx Oc Oo Op Ox Pa Pc Pf Po Pq Qc Ql Qo Qq Sc So
+	Sq St Sx Sy Ta Tn Ux Va Vt Xc Xo Xr`)
+
+func macroSet(names string) map[string]bool {
+	set := map[string]bool{}
+	for _, name := range strings.Fields(names) {
+		set[name] = true
+	}
+	return set
+}
+
+// The words of a line of mdoc prose with the macros and markup removed.
+// Flags keep their dash, so .Fl v becomes -v, and a bare .Nm is the
+// command's name.
+func mdocText(tokens []string, name string) string {
+	if len(tokens) == 0 {
+		return ""
+	}
+	macroLine := strings.HasPrefix(tokens[0], ".")
+	if macroLine && !inlineMacros[tokens[0][1:]] {
+		return ""
+	}
+	words := []string{}
+	macro := ""
+	values := 0
+	for i, token := range tokens {
+		if macroLine && (i == 0 || inlineMacros[token]) {
+			macro = macroName(token)
+			values = 0
+			// a bare .Nm stands for the name of the command
+			if _, j := macroValue(tokens, i); macro == "Nm" && j < 0 && name != "" {
+				words = append(words, name)
+			}
+			continue
+		}
+		token = strings.ReplaceAll(stripMarkup(token), "\\-", "-")
+		values++
+		switch {
+		case token == "":
+		case isPunctuation(token) && len(words) > 0:
+			words[len(words)-1] = words[len(words)-1] + token
+		case macro == "Fl":
+			words = append(words, "-"+token)
+		case macro == "Xr" && values == 2 && len(words) > 0:
+			// .Xr ssh-agent 1 is ssh-agent(1)
+			words[len(words)-1] = words[len(words)-1] + "(" + token + ")"
+		default:
+			words = append(words, token)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// Names a terse synopsis uses to stand for all of a command's flags, as
+// in cmd [options]
+var genericOptionNames = []string{"option", "options", "opts", "flags"}
+
+// Whether the parameter stands for all the command's flags rather than
+// being a real argument
+func isGenericOptions(p Parameter) bool {
+	if p.HasFlags || !p.HasArgument {
+		return false
+	}
+	for _, name := range genericOptionNames {
+		if strings.EqualFold(p.Argument, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// The flags listed by .It Fl entries in the DESCRIPTION or OPTIONS
+// section as optional parameters, each with any argument its entry
+// gives, eg [-p port] for .It Fl p Ar port. A flag listed more than once
+// is only given the first time.
+func optionParameters(lines []string, opts ParseOptions) []Parameter {
+	params := []Parameter{}
+	seen := map[string]bool{}
+	inSection := false
+	for _, line := range lines {
+		tokens := tokenize(line)
+		if len(tokens) == 0 {
+			continue
+		}
+		if tokens[0] == ".Sh" || tokens[0] == ".SH" {
+			inSection = isOptionSection(tokens)
+			continue
+		}
+		if !inSection || tokens[0] != ".It" || len(tokens) < 2 || tokens[1] != "Fl" {
+			continue
+		}
+		p, err := buildParameter(tokens[1:], opts)
+		if err != nil || !p.HasFlags {
+			continue
+		}
+		key := strings.Join(p.Flags, " ")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		p.Optional = true
+		params = append(params, p)
+	}
+	return params
+}
+
+// Replace a generic [options] in each syntax with the flags the page
+// describes, so that a command with a terse synopsis such as
+// cmd [options] file still has flags to complete. Commands whose
+// synopsis gives any flags of its own are left alone.
+func expandGenericOptions(c Command, lines []string, opts ParseOptions) Command {
+	if len(c.distinctFlags()) > 0 {
+		return c
+	}
+	options := optionParameters(lines, opts)
+	if len(options) == 0 {
+		return c
+	}
+	syntaxes := []Syntax{}
+	for _, syn := range c.Syntaxes {
+		params := []Parameter{}
+		for _, p := range syn.Parameters {
+			if isGenericOptions(p) {
+				params = append(params, options...)
+			} else {
+				params = append(params, p)
+			}
+		}
+		syntaxes = append(syntaxes, Syntax{Parameters: params})
+	}
+	c.Syntaxes = syntaxes
+	return c
+}
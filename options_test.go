@@ -0,0 +1,55 @@
+package kgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestOptionHelp(t *testing.T) {
+	page := mdocPage(".Op Fl v\n.Op Fl p Ar port") + ".Sh OPTIONS\n.Bl -tag -width Ds\n" +
+		".It Fl p Ar port\nPort to connect to\non the remote host.\n" +
+		".It Fl v , Fl \\-verbose\nBe\n.Em verbose .\n.Pp\nMore so when repeated.\n" +
+		".It Fl 4 Fl 6\nUse IPv4 or IPv6.\n" +
+		".It Cm start\nNot a flag.\n.El\n"
+	c, err := ParseManText(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"-p":        "Port to connect to on the remote host.",
+		"-v":        "Be verbose.",
+		"--verbose": "Be verbose.",
+		"-4":        "Use IPv4 or IPv6.",
+		"-6":        "Use IPv4 or IPv6.",
+	}
+	if len(c.Options) != len(want) {
+		t.Errorf("got %d options, want %d: %+v", len(c.Options), len(want), c.Options)
+	}
+	for flag, description := range want {
+		if got := c.Options[flag].Description; got != description {
+			t.Errorf("%s: got %q, want %q", flag, got, description)
+		}
+	}
+}
+
+func TestExpandGenericOptions(t *testing.T) {
+	list := ".Sh OPTIONS\n.Bl -tag\n.It Fl v\nBe verbose.\n.It Fl o Ar file\nWrite to file.\n.It Fl v\nAgain.\n.El\n"
+	tests := []struct {
+		synopsis string
+		want     []string
+	}{
+		{".Op Ar options\n.Ar file", []string{"foo [-v] [-o file] <file>"}},
+		{".Op Ar OPTIONS", []string{"foo [-v] [-o file]"}},
+		// a synopsis giving flags of its own keeps them
+		{".Op Fl q\n.Op Ar options", []string{"foo [-q] [options]"}},
+	}
+	for _, test := range tests {
+		c, err := ParseManText(mdocPage(test.synopsis) + list)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := c.Usage(); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: usage %q, want %q", test.synopsis, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package kgo
+
+import (
+	"io"
+)
+
+// A parser which can be taught synopsis macros beyond the default set,
+// such as a vendor's extensions to mdoc, eg
+//
+//	p := Parser{Macros: append(DefaultMacros(), ".Xyz")}
+//	command, err := p.ParseFile("/usr/share/man/man1/ls.1")
+//
+// The zero value parses in the same way as ParseFile.
+type Parser struct {
+	// The macros a synopsis line may use, eg .Fl. Lines using none of
+	// them are skipped. Nil means DefaultMacros.
+	Macros  []string
+	Options ParseOptions
+}
+
+func (p Parser) options() ParseOptions {
+	opts := p.Options
+	opts.macros = p.Macros
+	return opts
+}
+
+// Parse the man page at path, which may be gzipped
+func (p Parser) ParseFile(path string) (Command, error) {
+	return manfileToCommand(path, p.options())
+}
+
+// Parse a man page read from r
+func (p Parser) ParseReader(r io.Reader) (Command, error) {
+	lines, err := readLines(r)
+	if err != nil {
+		return Command{}, err
+	}
+	return linesToCommand(lines, p.options())
+}
+
+// Parse every man page in a directory, as ParseManFiles does
+func (p Parser) ParseManFiles(path string, rangeLower int, rangeUpper int) ([]Command, Stats, error) {
+	return ParseManFiles(path, rangeLower, rangeUpper, p.options())
+}
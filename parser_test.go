@@ -0,0 +1,49 @@
+package kgo
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestStrict(t *testing.T) {
+	page := mdocPage(".Op Fl a\n.Xyz foo")
+	if _, err := ParseManText(page); err != nil {
+		t.Errorf("not strict: %v", err)
+	}
+	_, err := Parser{Options: ParseOptions{Strict: true}}.ParseReader(strings.NewReader(page))
+	if !errors.Is(err, ErrUnknownMacro) || !strings.Contains(err.Error(), ".Xyz") {
+		t.Errorf("strict: got %v, want %v naming .Xyz", err, ErrUnknownMacro)
+	}
+	// a macro the parser is taught isn't unknown
+	p := Parser{Macros: append(DefaultMacros(), ".Xyz"), Options: ParseOptions{Strict: true}}
+	if _, err := p.ParseReader(strings.NewReader(page)); err != nil {
+		t.Errorf("strict with .Xyz known: %v", err)
+	}
+}
+
+func TestParserMacros(t *testing.T) {
+	page := mdocPage(".Op Fl a\n.Fl b\n.Ar file")
+	p := Parser{Macros: []string{".Nm", ".Fl"}}
+	c, err := p.ParseReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.Usage(), []string{"foo -b"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("usage %q, want %q", got, want)
+	}
+	c, err = Parser{}.ParseReader(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := c.Usage(), []string{"foo [-a] -b file"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("default usage %q, want %q", got, want)
+	}
+	// changing the defaults returned doesn't change the parser's
+	macros := DefaultMacros()
+	macros[0] = ".Xyz"
+	if DefaultMacros()[0] != ".Nm" {
+		t.Errorf("DefaultMacros shares its array")
+	}
+}
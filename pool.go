@@ -0,0 +1,149 @@
+package kgo
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// The outcome of parsing a single man page
+type Result struct {
+	Path    string
+	Command Command
+	Err     error
+	// The position of the file among those being parsed, which tells
+	// apart results for the same file listed twice
+	index int
+}
+
+// Parse every man page in a directory, sending each result on the
+// returned channel as soon as it's ready. The channel is closed once all
+// the pages have been parsed or the context is cancelled. If the
+// directory can't be read the only result is the error for it.
+func ParseDirChan(ctx context.Context, path string) <-chan Result {
+	results := make(chan Result)
+	go func() {
+		defer close(results)
+		files, err := getFileList(path)
+		if err != nil {
+			select {
+			case results <- Result{Path: path, Err: err}:
+			case <-ctx.Done():
+			}
+			return
+		}
+		parseFilesInto(ctx, files, ParseOptions{}, runtime.NumCPU(), results)
+	}()
+	return results
+}
+
+// Parse every man page at any depth under root, such as every section of
+// /usr/share/man, returning the commands of those that parsed in the
+// order they're found. Parsing stops soon after the context is cancelled,
+// returning the commands so far and the context's error.
+func ParseDir(ctx context.Context, root string) ([]Command, error) {
+	manfiles, err := getManFiles(root)
+	if err != nil {
+		return nil, err
+	}
+	files := []string{}
+	for _, manfile := range manfiles {
+		files = append(files, manfile.Path)
+	}
+
+	commands := []Command{}
+	for result := range parseFilesOrdered(ctx, files, ParseOptions{}) {
+		if result.Err == nil {
+			commands = append(commands, result.Command)
+		}
+	}
+	return commands, ctx.Err()
+}
+
+// Parse the files using a pool of opts.Workers workers, or one per CPU if
+// that isn't set, sending the results on the returned channel in the same
+// order as the files. The channel is closed once every file has been
+// parsed or the context is cancelled.
+func parseFilesOrdered(ctx context.Context, files []string, opts ParseOptions) <-chan Result {
+	workers := opts.Workers
+	if workers < 1 {
+		workers = runtime.NumCPU()
+	}
+	unordered := make(chan Result)
+	go func() {
+		defer close(unordered)
+		parseFilesInto(ctx, files, opts, workers, unordered)
+	}()
+
+	ordered := make(chan Result)
+	go func() {
+		defer close(ordered)
+		// Hold on to results which arrive before those of earlier files
+		pending := map[int]Result{}
+		next := 0
+		for result := range unordered {
+			pending[result.index] = result
+			for next < len(files) {
+				r, ok := pending[next]
+				if !ok {
+					break
+				}
+				delete(pending, next)
+				next++
+				select {
+				case ordered <- r:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+	return ordered
+}
+
+// Parse the files using a pool of workers, sending results on out in
+// the order they complete. Returns once every file has been parsed or
+// the context is cancelled.
+func parseFilesInto(ctx context.Context, files []string, opts ParseOptions, workers int, out chan<- Result) {
+	if workers < 1 {
+		workers = 1
+	}
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range indexes {
+				path := files[i]
+				// don't start on another file once cancelled
+				if ctx.Err() != nil {
+					return
+				}
+				var command Command
+				var err error
+				if opts.cache != nil {
+					command, err = opts.cache.parse(path, opts)
+				} else {
+					command, err = manfileToCommand(path, opts)
+				}
+				select {
+				case out <- Result{Path: path, Command: command, Err: err, index: i}:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}()
+	}
+
+feed:
+	for i := range files {
+		select {
+		case indexes <- i:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(indexes)
+	wg.Wait()
+}
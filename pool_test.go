@@ -0,0 +1,106 @@
+package kgo
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+func TestParseFilesOrdered(t *testing.T) {
+	files := []string{
+		"testdata/man1/tar.1", "testdata/man1/ls.1", "testdata/man1/tar.1",
+		"testdata/man1/gzip.1.gz", "testdata/man1/ls.1", "testdata/man1/rlogin.1",
+	}
+	want := []Command{}
+	for _, file := range files {
+		c, err := manfileToCommand(file, ParseOptions{})
+		if err != nil {
+			t.Fatalf("%s: %v", file, err)
+		}
+		want = append(want, c)
+	}
+	for _, workers := range []int{1, 4} {
+		got := []Result{}
+		for result := range parseFilesOrdered(context.Background(), files, ParseOptions{Workers: workers}) {
+			if result.Err != nil {
+				t.Errorf("%s: %v", result.Path, result.Err)
+			}
+			got = append(got, result)
+		}
+		if len(got) != len(files) {
+			t.Fatalf("%d workers: got %d results, want %d", workers, len(got), len(files))
+		}
+		for i := range files {
+			if got[i].Path != files[i] {
+				t.Errorf("%d workers: result %d is %s, want %s", workers, i, got[i].Path, files[i])
+			}
+			if !got[i].Command.Equal(want[i]) {
+				t.Errorf("%d workers: %s parsed as\n%+v\nwant\n%+v", workers, files[i], got[i].Command, want[i])
+			}
+		}
+	}
+}
+
+// Parse the fixtures with a single worker and with one per CPU
+func BenchmarkParseDir(b *testing.B) {
+	for name, workers := range map[string]int{"sequential": 1, "parallel": runtime.NumCPU()} {
+		b.Run(name, func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				if _, _, err := ParseManFiles("testdata/man1", 0, 0, ParseOptions{Workers: workers}); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func TestParseDirChan(t *testing.T) {
+	got := map[string]bool{}
+	for result := range ParseDirChan(context.Background(), "testdata/man1") {
+		if result.Err != nil {
+			t.Errorf("%s: %v", result.Path, result.Err)
+		}
+		got[filepath.Base(result.Path)] = true
+	}
+	if len(got) != 6 || !got["ls.1"] || !got["gzip.1.gz"] {
+		t.Errorf("got results for %v, want each fixture", got)
+	}
+
+	results := ParseDirChan(context.Background(), "testdata/no-such-dir")
+	if r := <-results; r.Err == nil {
+		t.Error("no error for a missing directory")
+	}
+	if _, ok := <-results; ok {
+		t.Error("more than one result for a missing directory")
+	}
+
+	// the channel closes once cancelled, without reading every result
+	ctx, cancel := context.WithCancel(context.Background())
+	results = ParseDirChan(ctx, "testdata/man1")
+	<-results
+	cancel()
+	for range results {
+	}
+}
+
+func TestParseDirCancelled(t *testing.T) {
+	commands, err := ParseDir(context.Background(), "testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commands) != 6 {
+		t.Errorf("got %d commands, want 6", len(commands))
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	commands, err = ParseDir(ctx, "testdata")
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got %v, want %v", err, context.Canceled)
+	}
+	if len(commands) != 0 {
+		t.Errorf("%d pages parsed after cancelling", len(commands))
+	}
+}
@@ -0,0 +1,72 @@
+package kgo
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Phrases in an option's description naming other options it depends on.
+// Only the explicit forms are recognised, eg:
+//
+//	requires -a
+//	(requires --foo or --bar)
+//	only valid with -b
+//	only with -b
+//	only used with -b
+//	only meaningful in combination with -b
+var requiresPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\brequires\s+((?:the\s+)?-` + flagListPattern + `)`),
+	regexp.MustCompile(`(?i)\bonly\s+(?:valid\s+|used\s+|meaningful\s+|useful\s+)?(?:with|when\s+used\s+with|in\s+combination\s+with)\s+((?:the\s+)?-` + flagListPattern + `)`),
+}
+
+// A run of one or more flags separated by commas, "and" or "or"
+const flagListPattern = `-?[\w][\w-]*(?:\s*(?:,|and|or)\s*-{1,2}[\w][\w-]*)*`
+
+var flagPattern = regexp.MustCompile(`-{1,2}[\w][\w-]*`)
+
+// Extract the flags an option's description says it depends on
+func parseRequires(description string) []string {
+	requires := []string{}
+	for _, re := range requiresPatterns {
+		for _, match := range re.FindAllStringSubmatch(description, -1) {
+			requires = append(requires, flagPattern.FindAllString(match[1], -1)...)
+		}
+	}
+	return requires
+}
+
+// Phrases in an option's description giving its default value, eg:
+//
+//	(default: 10)
+//	(default 10)
+//	defaults to /tmp
+//	the default is /tmp
+//	default value is 10
+var defaultPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)\(default:?\s+([^)]+)\)`),
+	regexp.MustCompile(`(?i)\bdefaults\s+to\s+(\S+)`),
+	regexp.MustCompile(`(?i)\bdefault(?:\s+value)?\s+is\s+(\S+)`),
+}
+
+// Words which start a phrase rather than being a value themselves, as
+// in "the default is to print everything" or "defaults to the current
+// locale"
+var functionWords = map[string]bool{
+	"a": true, "an": true, "the": true, "to": true, "that": true,
+	"this": true, "its": true, "their": true, "your": true, "whatever": true,
+	"whichever": true, "used": true, "set": true, "taken": true, "given": true,
+}
+
+// Extract the default value an option's description gives, or empty if
+// it doesn't clearly give one
+func parseDefault(description string) string {
+	for _, re := range defaultPatterns {
+		if match := re.FindStringSubmatch(description); match != nil {
+			value := strings.Trim(strings.TrimSpace(match[1]), ".,;:\"'")
+			if !functionWords[strings.ToLower(value)] {
+				return value
+			}
+		}
+	}
+	return ""
+}
@@ -0,0 +1,48 @@
+package kgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseRequires(t *testing.T) {
+	tests := []struct {
+		description string
+		want        []string
+	}{
+		{"Follow links. This option requires -a.", []string{"-a"}},
+		{"Compress the output (requires --gzip or --bzip2).", []string{"--gzip", "--bzip2"}},
+		{"Set the depth, only valid with -r.", []string{"-r"}},
+		{"Only meaningful in combination with the -l and -t options.", []string{"-l", "-t"}},
+		{"Only used with --long.", []string{"--long"}},
+		{"Be verbose. Use twice for more.", []string{}},
+		{"This requires root.", []string{}},
+	}
+	for _, test := range tests {
+		if got := parseRequires(test.description); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %q, want %q", test.description, got, test.want)
+		}
+	}
+}
+
+func TestParseDefault(t *testing.T) {
+	tests := []struct {
+		description string
+		want        string
+	}{
+		{"Retry this many times (default: 10).", "10"},
+		{"Wait between tries (default 5s).", "5s"},
+		{"The directory to use, defaults to /tmp.", "/tmp"},
+		{"Sets the mode. The default is \"fast\".", "fast"},
+		{"The default value is 4096.", "4096"},
+		{"Use the default settings.", ""},
+		{"Print defaults and exit.", ""},
+		{"The default is to print everything.", ""},
+		{"Sort order defaults to the current locale.", ""},
+	}
+	for _, test := range tests {
+		if got := parseDefault(test.description); got != test.want {
+			t.Errorf("%q: got %q, want %q", test.description, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package kgo
+
+import (
+	"fmt"
+	"sort"
+)
+
+// How many commands in a corpus accept a flag, and what it most often
+// means
+type FlagCount struct {
+	Flag  string
+	Count int
+	// The summary most of the commands describing the flag give it, or ""
+	// if none describe it
+	Description string
+}
+
+// Count the commands accepting each flag across a set of commands, most
+// common first. A command is counted once per flag however many of its
+// syntaxes accept it.
+func FlagFrequency(commands []Command) []FlagCount {
+	counts := map[string]int{}
+	descriptions := map[string]map[string]int{}
+	for _, c := range commands {
+		for _, name := range c.distinctFlags() {
+			counts[name]++
+			if summary := c.Options[name].summary(); summary != "" {
+				if descriptions[name] == nil {
+					descriptions[name] = map[string]int{}
+				}
+				descriptions[name][summary]++
+			}
+		}
+	}
+
+	report := []FlagCount{}
+	for flag, count := range counts {
+		report = append(report, FlagCount{Flag: flag, Count: count, Description: mostCommon(descriptions[flag])})
+	}
+	sort.Slice(report, func(i, j int) bool {
+		if report[i].Count != report[j].Count {
+			return report[i].Count > report[j].Count
+		}
+		return report[i].Flag < report[j].Flag
+	})
+	return report
+}
+
+// The commands accepting each flag across a set of commands, eg -v to
+// every command with a verbose flag. Each list of names is sorted and
+// only names each command once however many syntaxes accept the flag.
+func FlagIndex(commands []Command) map[string][]string {
+	index := map[string][]string{}
+	seen := map[string]bool{}
+	for _, c := range commands {
+		for _, name := range c.distinctFlags() {
+			if key := name + " " + c.Name; !seen[key] {
+				seen[key] = true
+				index[name] = append(index[name], c.Name)
+			}
+		}
+	}
+	for _, names := range index {
+		sort.Strings(names)
+	}
+	return index
+}
+
+// The string counted most often, the first in order on a tie so the
+// report is stable
+func mostCommon(counts map[string]int) string {
+	ret := ""
+	for s, count := range counts {
+		if best := counts[ret]; ret == "" || count > best || count == best && s < ret {
+			ret = s
+		}
+	}
+	return ret
+}
+
+// Every flag the command accepts in any of its syntaxes, each given once
+// in the order they first appear
+func (c Command) distinctFlags() []string {
+	flags := []string{}
+	seen := map[string]bool{}
+	for _, p := range c.allParameters() {
+		for _, name := range p.flagNames() {
+			if !seen[name] {
+				seen[name] = true
+				flags = append(flags, name)
+			}
+		}
+	}
+	return flags
+}
+
+// Lay the flag frequency report out as a table of each flag, the number
+// of commands accepting it and its most common description
+func FormatFlagReport(report []FlagCount) string {
+	ret := ""
+	for _, fc := range report {
+		line := fmt.Sprintf("%-20s %6d", fc.Flag, fc.Count)
+		if fc.Description != "" {
+			line = line + "  " + fc.Description
+		}
+		ret = ret + line + "\n"
+	}
+	return ret
+}
@@ -0,0 +1,57 @@
+package kgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFlagFrequency(t *testing.T) {
+	commands := []Command{}
+	for _, page := range []string{
+		mdocPage(".Op Fl v\n.Op Fl q\n.Nm foo\n.Fl v Ar file") + ".Bl -tag\n.It Fl v\nBe verbose. Really.\n.El\n",
+		mdocPage(".Op Fl v") + ".Bl -tag\n.It Fl v\nBe verbose.\n.El\n",
+		mdocPage(".Op Fl v") + ".Bl -tag\n.It Fl v\nPrint the version.\n.El\n",
+	} {
+		c, err := ParseManText(page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		commands = append(commands, c)
+	}
+	want := []FlagCount{
+		{Flag: "-v", Count: 3, Description: "Be verbose."},
+		{Flag: "-q", Count: 1},
+	}
+	got := FlagFrequency(commands)
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+	table := "-v                        3  Be verbose.\n-q                        1\n"
+	if out := FormatFlagReport(got); out != table {
+		t.Errorf("table\n%s\nwant\n%s", out, table)
+	}
+}
+
+func TestFlagIndex(t *testing.T) {
+	commands := []Command{}
+	for _, page := range []struct{ name, synopsis string }{
+		{"ls", ".Op Fl a\n.Op Fl l\n.Nm ls\n.Fl a"},
+		{"cp", ".Op Fl a\n.Op Fl \\-verbose"},
+		{"rm", ".Op Fl \\-verbose"},
+	} {
+		c, err := ParseManText(mdocPage(page.synopsis))
+		if err != nil {
+			t.Fatal(err)
+		}
+		c.Name = page.name
+		commands = append(commands, c)
+	}
+	want := map[string][]string{
+		"-a":        {"cp", "ls"},
+		"-l":        {"ls"},
+		"--verbose": {"cp", "rm"},
+	}
+	if got := FlagIndex(commands); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,54 @@
+package kgo
+
+// The signals that go into a command's confidence score
+type ScoreFactors struct {
+	// Parsed by a best effort path rather than from mdoc macros
+	BestEffort bool
+	// Synopsis lines starting with a macro the parser doesn't know
+	UnknownMacros int
+	// Whether the page defined the command's name
+	HasName bool
+	// Whether any syntax was produced at all
+	HasSyntax bool
+}
+
+// How much a single unknown macro, and all of them together, reduce
+// the score
+const (
+	unknownMacroPenalty    = 0.05
+	maxUnknownMacroPenalty = 0.3
+)
+
+func (c Command) ScoreFactors() ScoreFactors {
+	return ScoreFactors{
+		BestEffort:    c.LowConfidence,
+		UnknownMacros: c.UnknownMacros,
+		HasName:       c.Name != "",
+		HasSyntax:     len(c.Syntaxes) > 0,
+	}
+}
+
+// How far the parse can be trusted, from 0 for nothing useful to 1 for
+// a clean mdoc synopsis
+func (c Command) Score() float64 {
+	f := c.ScoreFactors()
+	if !f.HasSyntax {
+		return 0
+	}
+	score := 1.0
+	if f.BestEffort {
+		score = score * 0.5
+	}
+	if !f.HasName {
+		score = score - 0.2
+	}
+	penalty := float64(f.UnknownMacros) * unknownMacroPenalty
+	if penalty > maxUnknownMacroPenalty {
+		penalty = maxUnknownMacroPenalty
+	}
+	score = score - penalty
+	if score < 0 {
+		return 0
+	}
+	return score
+}
@@ -0,0 +1,35 @@
+package kgo
+
+import (
+	"testing"
+)
+
+func TestScoreOrdering(t *testing.T) {
+	parse := func(page string) Command {
+		t.Helper()
+		c, err := ParseManText(page)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return c
+	}
+	clean := parse(mdocPage(".Op Fl a"))
+	unknown := parse(mdocPage(".Op Fl a\n.Xyz b\n.Xyz c"))
+	man := parse(manPage(`\fBfoo\fR [\-a]`))
+	literal := parse(".Dd January 1, 2024\n.Dt FOO 1\n.Os\n.Sh SYNOPSIS\n.Bd -literal\nfoo [-a]\n.Ed\n.Sh DESCRIPTION\nDoes things.\n")
+
+	if clean.Score() != 1 {
+		t.Errorf("clean mdoc scores %v, want 1", clean.Score())
+	}
+	if f := unknown.ScoreFactors(); f.UnknownMacros != 2 || !(unknown.Score() < clean.Score()) {
+		t.Errorf("unknown macros %+v score %v, want 2 and below %v", f, unknown.Score(), clean.Score())
+	}
+	for name, c := range map[string]Command{"man(7)": man, "literal": literal} {
+		if !c.ScoreFactors().BestEffort || !(c.Score() < unknown.Score()) {
+			t.Errorf("%s scores %v with %+v, want best effort below %v", name, c.Score(), c.ScoreFactors(), unknown.Score())
+		}
+	}
+	if s := (Command{Name: "foo"}).Score(); s != 0 {
+		t.Errorf("no syntaxes scores %v, want 0", s)
+	}
+}
@@ -0,0 +1,99 @@
+package kgo
+
+import (
+	"sort"
+	"strings"
+)
+
+// How well a command matches a search, lower being better
+type searchScore struct {
+	// Exact name, name prefix, name substring, misspelt name, then
+	// description
+	tier     int
+	distance int
+}
+
+// The commands whose names match the query, best first, for picking a
+// command from a parsed corpus. Names which are the query, start with
+// it or contain it come first, then names a few typos away from it, eg
+// grep for grpe, and finally commands whose description mentions it.
+// Matching ignores case.
+func Search(commands []Command, query string) []Command {
+	query = strings.ToLower(strings.TrimSpace(query))
+	if query == "" {
+		return []Command{}
+	}
+	// allow roughly one typo for every three letters
+	maxDistance := 1 + len(query)/3
+
+	type match struct {
+		command Command
+		score   searchScore
+	}
+	matches := []match{}
+	for _, c := range commands {
+		name := strings.ToLower(c.Name)
+		d := editDistance(query, name)
+		var score searchScore
+		switch {
+		case name == query:
+			score = searchScore{0, 0}
+		case strings.HasPrefix(name, query):
+			score = searchScore{1, d}
+		case strings.Contains(name, query):
+			score = searchScore{2, d}
+		case d <= maxDistance:
+			score = searchScore{3, d}
+		case strings.Contains(strings.ToLower(c.Description), query):
+			score = searchScore{4, d}
+		default:
+			continue
+		}
+		matches = append(matches, match{c, score})
+	}
+
+	sort.SliceStable(matches, func(i, j int) bool {
+		a, b := matches[i].score, matches[j].score
+		if a.tier != b.tier {
+			return a.tier < b.tier
+		}
+		if a.distance != b.distance {
+			return a.distance < b.distance
+		}
+		return matches[i].command.Name < matches[j].command.Name
+	})
+	ret := []Command{}
+	for _, m := range matches {
+		ret = append(ret, m.command)
+	}
+	return ret
+}
+
+// The number of insertions, deletions, substitutions and swaps of
+// adjacent letters turning a into b, so a transposition such as grpe for
+// grep counts as a single typo
+func editDistance(a, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	// rows i-2, i-1 and i of the distance table
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return prev[len(rb)]
+}
@@ -0,0 +1,52 @@
+package kgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSearch(t *testing.T) {
+	commands := []Command{
+		{Name: "zgrep"}, {Name: "grepdiff"}, {Name: "git"}, {Name: "egrep"},
+		{Name: "ls", Description: "list directory contents"}, {Name: "grep"},
+	}
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{"grep", []string{"grep", "grepdiff", "egrep", "zgrep"}},
+		{"GREP ", []string{"grep", "grepdiff", "egrep", "zgrep"}},
+		{"grpe", []string{"grep", "egrep", "zgrep"}},
+		{"directory", []string{"ls"}},
+		{"", []string{}},
+		{"nothing", []string{}},
+	}
+	for _, test := range tests {
+		got := []string{}
+		for _, c := range Search(commands, test.query) {
+			got = append(got, c.Name)
+		}
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%q: got %q, want %q", test.query, got, test.want)
+		}
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want int
+	}{
+		{"grep", "grep", 0},
+		{"grpe", "grep", 1},
+		{"gre", "grep", 1},
+		{"grap", "grep", 1},
+		{"", "ls", 2},
+		{"kitten", "sitting", 3},
+	}
+	for _, test := range tests {
+		if got := editDistance(test.a, test.b); got != test.want {
+			t.Errorf("%s %s: got %d, want %d", test.a, test.b, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,76 @@
+package kgo
+
+import (
+	"sort"
+	"strings"
+)
+
+// A copy of the command in a stable order, so output generated from it
+// doesn't change just because a page was reordered. Within each syntax
+// the flags are sorted among themselves, short flags alphabetically
+// followed by long flags, while arguments and keywords keep their places.
+// The flags given together, as in .Fl a b c, are sorted in the same way
+// unless the last takes an argument.
+// The syntaxes are then sorted by their usage, and subcommands by name.
+func (c Command) Sorted() Command {
+	sorted := c
+	sorted.Syntaxes = []Syntax{}
+	for _, syn := range c.Syntaxes {
+		sorted.Syntaxes = append(sorted.Syntaxes, syn.sorted())
+	}
+	sort.SliceStable(sorted.Syntaxes, func(i, j int) bool {
+		return sorted.Syntaxes[i].usage("") < sorted.Syntaxes[j].usage("")
+	})
+
+	sorted.Subcommands = []Command{}
+	for _, sub := range c.Subcommands {
+		sorted.Subcommands = append(sorted.Subcommands, sub.Sorted())
+	}
+	sort.SliceStable(sorted.Subcommands, func(i, j int) bool {
+		return sorted.Subcommands[i].Name < sorted.Subcommands[j].Name
+	})
+	return sorted
+}
+
+func (s Syntax) sorted() Syntax {
+	params := []Parameter{}
+	// The positions of the flags, which they're shuffled between
+	slots := []int{}
+	flags := []Parameter{}
+	for i, p := range s.Parameters {
+		if p.HasFlags {
+			// the flag taking the argument stays last, next to it
+			if p.ArgumentFlag == "" {
+				p.Flags = append([]string{}, p.Flags...)
+				sort.SliceStable(p.Flags, func(i, j int) bool {
+					return flagLess(p.Flags[i], p.Flags[j])
+				})
+			}
+			slots = append(slots, i)
+			flags = append(flags, p)
+		}
+		params = append(params, p)
+	}
+	sort.SliceStable(flags, func(i, j int) bool {
+		return flagLess(flags[i].Flags[0], flags[j].Flags[0])
+	})
+	for i, slot := range slots {
+		params[slot] = flags[i]
+	}
+	return Syntax{Parameters: params}
+}
+
+// Whether flag a sorts before flag b: short flags come first, in
+// alphabetical order with the lowercase letter before the uppercase one,
+// then long options
+func flagLess(a, b string) bool {
+	_, longA := longOptionName(a)
+	_, longB := longOptionName(b)
+	if longA != longB {
+		return longB
+	}
+	if la, lb := strings.ToLower(a), strings.ToLower(b); la != lb {
+		return la < lb
+	}
+	return a > b
+}
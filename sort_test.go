@@ -0,0 +1,20 @@
+package kgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestSorted(t *testing.T) {
+	c, err := ParseManText(mdocPage(".Op Fl \\-verbose\n.Op Fl zA\n.Op Fl a\n.Ar file\n.Op Fl B Ar size\n.Nm foo\n.Fl \\-help"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"foo --help", "foo [-a] [-B size] [-zA] <file> [--verbose]"}
+	if got := c.Sorted().Usage(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := c.Usage(); got[0] != "foo [--verbose] [-zA] [-a] <file> [-B size]" {
+		t.Errorf("sorting changed the original: %q", got)
+	}
+}
@@ -0,0 +1,75 @@
+package kgo
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sort"
+)
+
+// A tally of how parsing a set of man pages went
+type Stats struct {
+	Files  int `json:"files"`
+	Parsed int `json:"parsed"`
+	// Pages that parsed but were left out by DropEmpty
+	Dropped int `json:"dropped"`
+	// The number of pages that failed to parse for each reason
+	Failures map[string]int `json:"failures"`
+}
+
+func newStats() Stats {
+	return Stats{Failures: map[string]int{}}
+}
+
+func (s *Stats) add(r Result, dropped bool) {
+	s.Files++
+	switch {
+	case r.Err != nil:
+		s.Failures[failureReason(r.Err)]++
+	case dropped:
+		s.Dropped++
+	default:
+		s.Parsed++
+	}
+}
+
+// A short reason for a page failing to parse, for grouping failures
+func failureReason(err error) string {
+	var pathErr *fs.PathError
+	switch {
+	case errors.Is(err, ErrNoSyntaxes):
+		return "no syntaxes"
+	case errors.Is(err, ErrNoSynopsis):
+		return "no synopsis"
+	case errors.Is(err, ErrUnknownMacro):
+		return "unknown macro"
+	case errors.Is(err, ErrRedirectLoop):
+		return "redirect loop"
+	case errors.As(err, &pathErr), errors.Is(err, gzip.ErrHeader),
+		errors.Is(err, gzip.ErrChecksum), errors.Is(err, io.ErrUnexpectedEOF):
+		return "read error"
+	}
+	return "other"
+}
+
+// A summary of the stats, with the failures in order of reason, eg
+//
+//	1200 files: 1100 parsed, 0 dropped, 100 failed
+//	  no syntaxes: 90
+//	  read error: 10
+func (s Stats) String() string {
+	failed := 0
+	reasons := []string{}
+	for reason, count := range s.Failures {
+		failed += count
+		reasons = append(reasons, reason)
+	}
+	sort.Strings(reasons)
+	ret := fmt.Sprintf("%d files: %d parsed, %d dropped, %d failed\n", s.Files, s.Parsed, s.Dropped, failed)
+	for _, reason := range reasons {
+		ret = ret + fmt.Sprintf("  %s: %d\n", reason, s.Failures[reason])
+	}
+	return ret
+}
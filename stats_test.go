@@ -0,0 +1,35 @@
+package kgo
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestStats(t *testing.T) {
+	root := t.TempDir()
+	writePage(t, root, "a.1", mdocPage(".Op Fl a"))
+	writePage(t, root, "b.1", mdocPage(".Cm start"))
+	writePage(t, root, "c.1", ".Dd January 1, 2024\n.Sh DESCRIPTION\nNo synopsis.\n")
+	writePage(t, root, "d.1.gz", "not gzipped")
+	writePage(t, root, "e.1", ".so man1/e.1\n")
+	commands, stats, err := ParseManFiles(filepath.Join(root, "man1"), 0, 0, ParseOptions{DropEmpty: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(commands) != 1 {
+		t.Errorf("got %d commands, want 1", len(commands))
+	}
+	want := Stats{Files: 5, Parsed: 1, Dropped: 1, Failures: map[string]int{
+		"no synopsis":   1,
+		"read error":    1,
+		"redirect loop": 1,
+	}}
+	if !reflect.DeepEqual(stats, want) {
+		t.Errorf("got %+v, want %+v", stats, want)
+	}
+	summary := "5 files: 1 parsed, 1 dropped, 3 failed\n  no synopsis: 1\n  read error: 1\n  redirect loop: 1\n"
+	if got := stats.String(); got != summary {
+		t.Errorf("summary\n%s\nwant\n%s", got, summary)
+	}
+}
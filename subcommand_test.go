@@ -0,0 +1,64 @@
+package kgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestKeywordSubcommands(t *testing.T) {
+	tests := []struct {
+		name     string
+		synopsis string
+	}{
+		{"lines", ".Nm git\n.Cm commit\n.Op Fl m Ar msg\n.Nm git\n.Cm remote\n.Cm add\n.Ar name"},
+		{"one line", ".Nm git Cm commit Op Fl m Ar msg\n.Nm git Cm remote Cm add Ar name"},
+	}
+	for _, test := range tests {
+		c, err := ParseManText(mdocPage(test.synopsis))
+		if err != nil {
+			t.Errorf("%s: %v", test.name, err)
+			continue
+		}
+		if want := []string{"foo commit", "foo remote", "foo remote add"}; !reflect.DeepEqual(c.SubcommandPaths(), want) {
+			t.Errorf("%s: subcommands %q, want %q", test.name, c.SubcommandPaths(), want)
+		}
+		if want := []string{"foo commit [-m msg]", "foo remote add <name>"}; !reflect.DeepEqual(c.Usage(), want) {
+			t.Errorf("%s: usage %q, want %q", test.name, c.Usage(), want)
+		}
+	}
+}
+
+func TestSubcommandPaths(t *testing.T) {
+	c := Command{Name: "git", Subcommands: []Command{
+		{Name: "commit"},
+		{Name: "remote", Subcommands: []Command{
+			{Name: "add"},
+			{Name: "set-url", Subcommands: []Command{{Name: "--add"}}},
+		}},
+	}}
+	want := []string{"git commit", "git remote", "git remote add", "git remote set-url", "git remote set-url --add"}
+	if got := c.SubcommandPaths(); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := (Command{Name: "ls"}).SubcommandPaths(); len(got) != 0 {
+		t.Errorf("ls has subcommands %q", got)
+	}
+}
+
+func TestLiteralKeywords(t *testing.T) {
+	checkUsages(t, []usageTest{
+		{".Ql start", []string{"foo start"}},
+		{".Li stop", []string{"foo stop"}},
+		{".Op Ql verbose", []string{"foo [verbose]"}},
+		{".Ql start | Ql stop", []string{"foo (start | stop)"}},
+	})
+	for _, macro := range []string{"Ql", "Li", "Cm"} {
+		c, err := ParseManText(mdocPage("." + macro + " status"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if p := c.Syntaxes[0].Parameters[0]; p.Keyword != "status" || p.HasArgument {
+			t.Errorf(".%s: got %+v, want the keyword status", macro, p)
+		}
+	}
+}
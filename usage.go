@@ -0,0 +1,135 @@
+package kgo
+
+import (
+	"strings"
+)
+
+// One usage line for each of the command's syntaxes, eg
+// ls [-a] [-l] [file ...]
+func (c Command) Usage() []string {
+	lines := []string{}
+	for _, syn := range c.Syntaxes {
+		lines = append(lines, syn.usage(c.Name))
+	}
+	return lines
+}
+
+// A single usage line for the syntax in the style of docopt, eg
+// cmd [-v] [-o file] <input> [files ...]
+func (s Syntax) usage(name string) string {
+	parts := []string{}
+	if name != "" {
+		parts = append(parts, name)
+	}
+	for _, p := range s.Parameters {
+		if u := p.usage(); u != "" {
+			parts = append(parts, u)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// The parameter as it appears in a usage line. Positional arguments are
+// shown in angle brackets unless they're optional, in which case the
+// whole parameter is in square brackets instead. Alternatives are
+// separated by a bar, in parentheses when one of them is required or in
+// braces when the page groups them that way. Placeholders are always in
+// angle brackets, and environment variables are marked with a dollar
+// sign.
+func (p Parameter) usage() string {
+	if len(p.Alternatives) > 0 {
+		alts := []string{}
+		for _, alt := range p.Alternatives {
+			if u := alt.usage(); u != "" {
+				alts = append(alts, u)
+			}
+		}
+		ret := strings.Join(alts, " | ")
+		switch {
+		case p.Choice && p.Optional:
+			return "[{" + ret + "}]"
+		case p.Choice:
+			return "{" + ret + "}"
+		case p.Optional:
+			return "[" + ret + "]"
+		}
+		return "(" + ret + ")"
+	}
+	parts := []string{}
+	if p.HasFlags {
+		names := p.flagNames()
+		n := len(names) - len(p.Aliases)
+		flag := strings.Join(names[:n], " ")
+		// synonyms can be given instead, eg -v | --verbose
+		if n < len(names) {
+			flag = flag + " | " + strings.Join(names[n:], " | ")
+		}
+		if p.Countable {
+			flag = flag + " ..."
+		}
+		parts = append(parts, flag)
+	}
+	if p.Stdin {
+		parts = append(parts, "-")
+	}
+	if p.Keyword != "" {
+		parts = append(parts, p.Keyword)
+	}
+	// environment variables are shown as they're expanded, eg $TERM,
+	// and paths as they're typed
+	if p.Environment != "" {
+		parts = append(parts, "$"+p.Environment)
+	}
+	if p.Path != "" {
+		parts = append(parts, p.Path)
+	}
+	arg := ""
+	if p.HasArgument {
+		arg = p.Argument
+		if p.Placeholder || (!p.HasFlags && !p.Optional) {
+			arg = "<" + arg + ">"
+		}
+		if p.Variadic {
+			arg = arg + " ..."
+		}
+		if !p.ArgumentLast {
+			parts = append(parts, arg)
+		}
+	}
+	// a flag joined to its argument, eg -Idir, or with an equals sign
+	// for a long option, eg --color=when
+	if p.NoSpace && p.HasFlags && p.HasArgument && !p.ArgumentLast && len(parts) > 1 {
+		last := len(parts) - 1
+		sep := ""
+		if _, long := longOptionName(p.ArgumentFlag); long {
+			sep = "="
+		}
+		parts = append(parts[:last-1], parts[last-1]+sep+parts[last])
+	}
+	ret := strings.Join(parts, " ")
+	if p.HasParameter && p.Parameter != nil {
+		if nested := p.Parameter.usage(); nested != "" {
+			sep := " "
+			if ret == "" || p.Parameter.NoSpace {
+				sep = ""
+			}
+			ret = ret + sep + nested
+		}
+	}
+	// an argument after the nested group, which may be joined to it,
+	// eg -D [bind_address]port
+	if p.ArgumentLast {
+		if p.NoSpace || ret == "" {
+			ret = ret + arg
+		} else {
+			ret = ret + " " + arg
+		}
+	}
+	if p.Choice && ret != "" {
+		ret = "{" + ret + "}"
+	}
+	if p.Optional && ret != "" {
+		ret = "[" + ret + "]"
+	}
+	return ret
+}
@@ -0,0 +1,20 @@
+package kgo
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestUsage(t *testing.T) {
+	checkUsages(t, []usageTest{
+		{".Op Fl v\n.Op Fl o Ar file\n.Ar input\n.Op Ar files ...\n.Nm foo\n.Fl h",
+			[]string{"foo [-v] [-o file] <input> [files ...]", "foo -h"}},
+		{".Op Fl \\-color Ns = Ns Ar when", []string{"foo [--color=when]"}},
+		{".Fl D Ns Ar name", []string{"foo -Dname"}},
+	})
+	// a parameter without a name for the command still renders
+	s := Syntax{Parameters: []Parameter{{HasArgument: true, Argument: "file"}}}
+	if got, want := (Command{Syntaxes: []Syntax{s}}).Usage(), []string{"<file>"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
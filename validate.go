@@ -0,0 +1,147 @@
+package kgo
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Returned by Validate when a parsed parameter is inconsistent, which
+// points to a bug in the parser rather than a problem with the page
+var ErrInvalidParameter = errors.New("invalid parameter")
+
+// Returned by Validate when an option's description was read as
+// requiring something that can't be a flag the option works with
+var ErrInvalidOption = errors.New("invalid option")
+
+// Returned by ValidateArgs when an invocation gives an option without any
+// of the flags its description says it requires
+var ErrMissingRequired = errors.New("missing required option")
+
+// Check the parameters of every syntax, and of any subcommands, are
+// consistent, and that each option only requires other flags. The error
+// names the first parameter or option that isn't.
+func (c Command) Validate() error {
+	for i, syn := range c.Syntaxes {
+		for j, p := range syn.Parameters {
+			if err := p.Validate(); err != nil {
+				return fmt.Errorf("%s syntax %d parameter %d: %w", c.Name, i+1, j+1, err)
+			}
+		}
+	}
+	flags := make([]string, 0, len(c.Options))
+	for flag := range c.Options {
+		flags = append(flags, flag)
+	}
+	sort.Strings(flags)
+	for _, flag := range flags {
+		for _, req := range c.Options[flag].Requires {
+			if req == flag || !strings.HasPrefix(req, "-") {
+				return fmt.Errorf("%s option %s: %w: requires %q", c.Name, flag, ErrInvalidOption, req)
+			}
+		}
+	}
+	for _, sub := range c.Subcommands {
+		if err := sub.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check the parameter and those nested in it are consistent: flags and
+// arguments are named when they're said to be present, and an argument
+// flag is one of the flags and takes the argument.
+func (p Parameter) Validate() error {
+	invalid := func(format string, args ...interface{}) error {
+		return fmt.Errorf("%w: "+format, append([]interface{}{ErrInvalidParameter}, args...)...)
+	}
+	switch {
+	case p.HasFlags && len(p.Flags) == 0:
+		return invalid("has flags but none are given")
+	case !p.HasFlags && len(p.Flags) > 0:
+		return invalid("flags %v given without hasflags", p.Flags)
+	case len(p.Aliases) > 0 && !p.HasFlags:
+		return invalid("aliases %v given without flags", p.Aliases)
+	case p.HasArgument && p.Argument == "":
+		return invalid("has an argument without a name")
+	case !p.HasArgument && p.Argument != "":
+		return invalid("argument %q given without hasargument", p.Argument)
+	case p.ArgumentFlag != "" && !p.HasArgument:
+		return invalid("flag %s takes an argument but there isn't one", p.ArgumentFlag)
+	case p.ArgumentLast && !(p.HasArgument && p.HasParameter):
+		return invalid("argument after a nested parameter, without both")
+	case p.HasParameter != (p.Parameter != nil):
+		return invalid("hasparameter is %v but the parameter is %v", p.HasParameter, p.Parameter)
+	}
+	for _, flag := range p.Flags {
+		if flag == "" {
+			return invalid("empty flag in %q", p.Flags)
+		}
+	}
+	if p.ArgumentFlag != "" && !containsString(p.Flags, p.ArgumentFlag) {
+		return invalid("argument flag %s isn't one of %v", p.ArgumentFlag, p.Flags)
+	}
+
+	if p.Parameter != nil {
+		if err := p.Parameter.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, alt := range p.Alternatives {
+		if err := alt.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Check an invocation of the command gives each option along with at
+// least one of the flags its description says it requires, eg -b with -x
+// when -x is "only valid with -b". A long option's =value is ignored,
+// a bundle such as -ab that isn't itself an option gives each letter, and
+// nothing after -- is an option. The error names the first option given
+// without what it requires.
+func (c Command) ValidateArgs(args []string) error {
+	given := []string{}
+	for _, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") || arg == "-" {
+			continue
+		}
+		flag, _, _ := strings.Cut(arg, "=")
+		if _, ok := c.Options[flag]; ok || strings.HasPrefix(flag, "--") {
+			given = append(given, flag)
+			continue
+		}
+		for _, letter := range flag[1:] {
+			given = append(given, "-"+string(letter))
+		}
+	}
+	for _, flag := range given {
+		requires := c.Options[flag].Requires
+		if len(requires) == 0 {
+			continue
+		}
+		found := false
+		for _, req := range requires {
+			found = found || containsString(given, req)
+		}
+		if !found {
+			return fmt.Errorf("%w: %s requires %s", ErrMissingRequired, flag, strings.Join(requires, " or "))
+		}
+	}
+	return nil
+}
+
+func containsString(list []string, s string) bool {
+	for _, v := range list {
+		if v == s {
+			return true
+		}
+	}
+	return false
+}
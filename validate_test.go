@@ -0,0 +1,77 @@
+package kgo
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestValidateArgs(t *testing.T) {
+	page := mdocPage(".Op Fl abx") + ".Bl -tag\n.It Fl x\nExpand tabs, only valid with -a or -b.\n.It Fl \\-depth\nHow deep to go (requires -r).\n.El\n"
+	c, err := ParseManText(page)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Validate(); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		args []string
+		ok   bool
+	}{
+		{[]string{"-x", "-a"}, true},
+		{[]string{"-bx"}, true},
+		{[]string{"-a"}, true},
+		{[]string{"-x"}, false},
+		{[]string{"--depth=2", "file"}, false},
+		{[]string{"--depth=2", "-r"}, true},
+		{[]string{"--", "-x"}, true},
+	}
+	for _, test := range tests {
+		err := c.ValidateArgs(test.args)
+		if test.ok && err != nil {
+			t.Errorf("%q: %v", test.args, err)
+		}
+		if !test.ok && !errors.Is(err, ErrMissingRequired) {
+			t.Errorf("%q: got %v, want %v", test.args, err, ErrMissingRequired)
+		}
+	}
+}
+
+func TestValidateRequiresItself(t *testing.T) {
+	c := Command{Name: "foo", Options: map[string]Option{"-a": {Requires: []string{"-a"}}}}
+	if err := c.Validate(); !errors.Is(err, ErrInvalidOption) {
+		t.Errorf("got %v, want %v", err, ErrInvalidOption)
+	}
+}
+
+func TestValidateParameters(t *testing.T) {
+	tests := []Parameter{
+		{HasFlags: true},
+		{Flags: []string{"a"}},
+		{HasArgument: true},
+		{Argument: "file"},
+		{HasFlags: true, Flags: []string{"a"}, ArgumentFlag: "a"},
+		{HasFlags: true, Flags: []string{"a"}, HasArgument: true, Argument: "file", ArgumentFlag: "b"},
+		{HasParameter: true},
+		{Parameter: &Parameter{HasFlags: true, Flags: []string{"a"}}},
+		{HasParameter: true, Parameter: &Parameter{HasFlags: true}},
+		{Alternatives: []Parameter{{HasFlags: true, Flags: []string{""}}}},
+	}
+	for _, p := range tests {
+		if err := p.Validate(); !errors.Is(err, ErrInvalidParameter) {
+			t.Errorf("%+v: got %v, want %v", p, err, ErrInvalidParameter)
+		}
+	}
+	c, err := ParseFile("testdata/man1/tar.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := c.Validate(); err != nil {
+		t.Errorf("tar: %v", err)
+	}
+	c.Syntaxes[0].Parameters[0] = Parameter{HasFlags: true}
+	if err := c.Validate(); !errors.Is(err, ErrInvalidParameter) || !strings.Contains(err.Error(), "syntax 1 parameter 1") {
+		t.Errorf("got %v, want %v naming the parameter", err, ErrInvalidParameter)
+	}
+}
@@ -0,0 +1,132 @@
+package kgo
+
+import (
+	"bytes"
+	"encoding/json"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// The command as a YAML document, which is easier to read and diff than
+// JSON. It has the same fields in the same order as the JSON, with any
+// nested parameter written inline under its parent, eg
+//
+//	name: ssh
+//	syntaxes:
+//	  - parameters:
+//	      - optional: true
+//	        nospace: false
+//	        hasargument: false
+//	        hasflags: true
+//	        flags:
+//	          - 46AaCfGgKkMNnqsTtVvXxYy
+//	        hasparameter: false
+//
+// The YAML is written from the JSON rather than the structs, so the
+// fields and their names match without a second set of tags.
+func (c Command) YAML() (string, error) {
+	data, err := json.Marshal(c)
+	if err != nil {
+		return "", err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	v, err := decodeOrdered(dec)
+	if err != nil {
+		return "", err
+	}
+	var b strings.Builder
+	enc := yaml.NewEncoder(&b)
+	enc.SetIndent(2)
+	if err := enc.Encode(yamlNode(v)); err != nil {
+		return "", err
+	}
+	if err := enc.Close(); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
+
+// A JSON object with its fields kept in order
+type orderedObject []orderedField
+
+type orderedField struct {
+	key   string
+	value interface{}
+}
+
+// Decode the next JSON value, keeping the order of object fields. Arrays
+// become []interface{} and scalars are left as the decoder gives them.
+func decodeOrdered(dec *json.Decoder) (interface{}, error) {
+	token, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+	switch token {
+	case json.Delim('{'):
+		obj := orderedObject{}
+		for dec.More() {
+			key, err := dec.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			obj = append(obj, orderedField{key.(string), value})
+		}
+		_, err := dec.Token()
+		return obj, err
+	case json.Delim('['):
+		list := []interface{}{}
+		for dec.More() {
+			value, err := decodeOrdered(dec)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, value)
+		}
+		_, err := dec.Token()
+		return list, err
+	}
+	return token, nil
+}
+
+// A decoded JSON value as a YAML node. Objects and arrays are written in
+// block style, a line per field or item, and strings which YAML would
+// read as something else, eg 0x10 or yes, are quoted.
+func yamlNode(v interface{}) *yaml.Node {
+	switch v := v.(type) {
+	case orderedObject:
+		node := &yaml.Node{Kind: yaml.MappingNode, Tag: "!!map"}
+		for _, field := range v {
+			node.Content = append(node.Content, yamlNode(field.key), yamlNode(field.value))
+		}
+		return node
+	case []interface{}:
+		node := &yaml.Node{Kind: yaml.SequenceNode, Tag: "!!seq"}
+		for _, item := range v {
+			node.Content = append(node.Content, yamlNode(item))
+		}
+		return node
+	case bool:
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!bool", Value: strconv.FormatBool(v)}
+	case json.Number:
+		tag := "!!int"
+		if _, err := v.Int64(); err != nil {
+			tag = "!!float"
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: tag, Value: v.String()}
+	case string:
+		// Encode quotes the strings YAML 1.1 reads as booleans too, eg yes
+		node := &yaml.Node{}
+		if err := node.Encode(v); err == nil {
+			return node
+		}
+		return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: v}
+	}
+	return &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!null", Value: "null"}
+}
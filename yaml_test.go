@@ -0,0 +1,43 @@
+package kgo
+
+import (
+	"testing"
+)
+
+func TestYAMLGolden(t *testing.T) {
+	c, err := ParseFile("testdata/man1/ls.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := c.YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	checkGolden(t, "testdata/golden/ls.yaml", []byte(got))
+}
+
+func TestYAMLQuoting(t *testing.T) {
+	c := Command{Name: "0x10", Description: "a: b", Section: "1", Syntaxes: []Syntax{}, Options: map[string]Option{
+		"-x": {Description: ".inf", Default: "1_000", Requires: []string{"yes", ".nan", "-y"}},
+	}}
+	want := `name: "0x10"
+description: 'a: b'
+options:
+  -x:
+    description: ".inf"
+    requires:
+      - "yes"
+      - ".nan"
+      - -y
+    default: "1_000"
+section: "1"
+syntaxes: []
+`
+	got, err := c.YAML()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+}